@@ -0,0 +1,254 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// BindTag is the struct tag read by BindParams()
+const BindTag = "goqa"
+
+// bindField is a struct field with a goqa tag
+type bindField struct {
+	param      string // param name, the field name when the tag has no name
+	def        string
+	hasDefault bool
+	required   bool
+}
+
+// parseBindTag reads a tag like "name=val1,default=11.1,required". A part
+// without "=" that isn't a flag continues the value before it, so list
+// defaults can be written like "default=1,2,3"
+func parseBindTag(fieldName, tag string) (bindField, error) {
+	f := bindField{param: fieldName}
+	last := ""
+	for _, part := range strings.Split(tag, ",") {
+		trimmed := strings.TrimSpace(part)
+		kv := strings.SplitN(trimmed, "=", 2)
+		switch {
+		case trimmed == "required":
+			f.required = true
+			last = ""
+		case len(kv) == 2 && kv[0] == "name":
+			f.param = kv[1]
+			last = "name"
+		case len(kv) == 2 && kv[0] == "default":
+			f.def = kv[1]
+			f.hasDefault = true
+			last = "default"
+		case last == "default":
+			f.def += "," + part
+		case trimmed == "":
+		default:
+			return f, fmt.Errorf("invalid %s tag option '%s'", BindTag, trimmed)
+		}
+	}
+	if f.param == "" {
+		f.param = fieldName
+	}
+	return f, nil
+}
+
+// BindParams sets the fields of the struct v points to from params. Fields
+// are set by their goqa tag:
+//
+//	type Test1 struct {
+//		goQA.TestCase
+//		Val1    float64       `goqa:"name=val1,default=11.1"`
+//		Val2    int           `goqa:"name=val2,required"`
+//		Hosts   []string      `goqa:"default=a,b"` // param "Hosts"
+//		Timeout time.Duration `goqa:"default=1m30s"`
+//	}
+//
+// Values are converted to the field type like the Parameters getters. A
+// missing param gets its default, which is also added to params, or keeps
+// the field value. Fields of embedded structs are set too. All errors are
+// returned together, naming the field and param
+func BindParams(v interface{}, params *Parameters) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("can't bind params to %T, expected a pointer to a struct", v)
+	}
+	errs := bindStruct(rv.Elem(), params, nil)
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func bindStruct(rv reflect.Value, params *Parameters, errs []string) []string {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, tagged := field.Tag.Lookup(BindTag)
+		if !tagged || tag == "-" {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct && field.PkgPath == "" {
+				errs = bindStruct(rv.Field(i), params, errs)
+			}
+			continue
+		}
+
+		f, err := parseBindTag(field.Name, tag)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("field '%s': %s", field.Name, err.Error()))
+			continue
+		}
+		if field.PkgPath != "" {
+			errs = append(errs, fmt.Sprintf("field '%s': not exported", field.Name))
+			continue
+		}
+
+		var value interface{} = f.def
+		if params.has(f.param) {
+			value, _ = params.GetParamValue(f.param)
+		} else if f.required {
+			errs = append(errs, fmt.Sprintf("field '%s': required parameter '%s' not found", field.Name, f.param))
+			continue
+		} else if !f.hasDefault {
+			continue
+		}
+
+		converted, err := convertValue(value, field.Type)
+		if err != nil {
+			msg := fmt.Sprintf("field '%s': parameter '%s': %s", field.Name, f.param, err.Error())
+			if param, ok := params.GetParam(f.param); ok && params.has(f.param) {
+				msg = fmt.Sprintf("%s (set by %s)", msg, param.Source())
+			} else {
+				msg = fmt.Sprintf("%s (default of %s tag)", msg, BindTag)
+			}
+			errs = append(errs, msg)
+			continue
+		}
+		rv.Field(i).Set(converted)
+		if !params.has(f.param) {
+			params.InitParam(f.param, converted.Interface())
+		}
+	}
+	return errs
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// convertValue converts value to type t with the to*() conversions
+func convertValue(value interface{}, t reflect.Type) (reflect.Value, error) {
+	if value != nil && reflect.TypeOf(value).AssignableTo(t) {
+		return reflect.ValueOf(value), nil
+	}
+
+	v := reflect.New(t).Elem()
+	switch {
+	case t == durationType:
+		d, err := toDuration(value)
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(int64(d))
+		return v, nil
+	case t == timeType:
+		tm, err := toTime(value)
+		if err != nil {
+			return v, err
+		}
+		v.Set(reflect.ValueOf(tm))
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := toInt64(value)
+		if err != nil {
+			return v, err
+		}
+		if v.OverflowInt(i) {
+			return v, fmt.Errorf("%d overflows %s", i, t)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := toInt64(value)
+		if err != nil {
+			return v, err
+		}
+		if i < 0 || v.OverflowUint(uint64(i)) {
+			return v, fmt.Errorf("%d overflows %s", i, t)
+		}
+		v.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat64(value)
+		if err != nil {
+			return v, err
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		s, err := toString(value)
+		if err != nil {
+			return v, err
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := toBool(value)
+		if err != nil {
+			return v, err
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		list, err := toList(value)
+		if err != nil {
+			return v, err
+		}
+		v = reflect.MakeSlice(t, 0, len(list))
+		for i, item := range list {
+			elem, err := convertValue(item, t.Elem())
+			if err != nil {
+				return v, fmt.Errorf("list element %d: %s", i, err.Error())
+			}
+			v = reflect.Append(v, elem)
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return v, fmt.Errorf("can't bind to %s, map keys must be strings", t)
+		}
+		if s, ok := value.(string); ok {
+			parsed, err := parseMapParam(s, "string")
+			if err != nil {
+				return v, err
+			}
+			value = parsed
+		}
+		m, err := toMap(value)
+		if err != nil {
+			return v, err
+		}
+		v = reflect.MakeMapWithSize(t, len(m))
+		for key, item := range m {
+			elem, err := convertValue(item, t.Elem())
+			if err != nil {
+				return v, fmt.Errorf("map element '%s': %s", key, err.Error())
+			}
+			v.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), elem)
+		}
+	default:
+		return v, fmt.Errorf("can't convert %v (%T) to %s", value, value, t)
+	}
+	return v, nil
+}
+
+// bindTest binds the params of tc, when it has any, to its fields
+func bindTest(tc Tester) error {
+	p, ok := tc.(paramHolder)
+	if !ok {
+		return nil
+	}
+	if reflect.ValueOf(tc).Kind() != reflect.Ptr || reflect.ValueOf(tc).Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return BindParams(tc, p.GetParams())
+}
@@ -0,0 +1,137 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// BindCommon is embedded in bindTarget. Fields of exported embedded structs are bound
+type BindCommon struct {
+	Region string `goqa:"name=region,default=eu"`
+}
+
+type bindTarget struct {
+	BindCommon
+	Val1    float64           `goqa:"name=val1,default=11.1"`
+	Val2    int               `goqa:"name=val2,required"`
+	Hosts   []string          `goqa:"default=a,b"`
+	Ports   []uint16          `goqa:"name=ports"`
+	Limits  map[string]int    `goqa:"name=limits,default=low=1,high=9"`
+	Timeout time.Duration     `goqa:"default=1m30s"`
+	Labels  map[string]string `goqa:"name=labels"`
+	Kept    string            `goqa:"name=kept"`
+	Ignored string            `goqa:"-"`
+}
+
+func TestBindParams(t *testing.T) {
+	params := Parameters{}
+	params.AddParam("val2", "42", "")
+	params.AddParam("ports", "[80, 443]", "")
+	params.AddParam("labels", map[string]interface{}{"env": "qa"}, "")
+	params.AddParam("Ignored", "x", "")
+	target := bindTarget{Kept: "before"}
+	if err := BindParams(&target, &params); err != nil {
+		t.Fatal(err)
+	}
+	want := bindTarget{
+		BindCommon: BindCommon{Region: "eu"},
+		Val1:       11.1,
+		Val2:       42,
+		Hosts:      []string{"a", "b"},
+		Ports:      []uint16{80, 443},
+		Limits:     map[string]int{"low": 1, "high": 9},
+		Timeout:    90 * time.Second,
+		Labels:     map[string]string{"env": "qa"},
+		Kept:       "before",
+	}
+	if !reflect.DeepEqual(target, want) {
+		t.Errorf("bound:\n%+v\nwant:\n%+v", target, want)
+	}
+	// defaults are added to the params
+	if value, ok := params.GetParamValue("val1"); !ok || value != 11.1 {
+		t.Errorf("default of val1 isn't added to the params: %v", value)
+	}
+	if _, ok := params.GetParamValue("kept"); ok {
+		t.Errorf("param without default is added")
+	}
+}
+
+func TestBindParamsErrors(t *testing.T) {
+	params := Parameters{}
+	params.AddParam("val1", "abc", "")
+	params.AddParam("ports", "80, 70000", "")
+	var target bindTarget
+	err := BindParams(&target, &params)
+	if err == nil {
+		t.Fatal("no error")
+	}
+	for _, want := range []string{
+		"field 'Val1': parameter 'val1': can't convert abc (string) to a float (set by",
+		"field 'Val2': required parameter 'val2' not found",
+		"field 'Ports': parameter 'ports': list element 1: 70000 overflows uint16",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't contain %q", err, want)
+		}
+	}
+
+	var bad struct {
+		Count  int `goqa:"default=many"`
+		Option int `goqa:"bogus"`
+		hidden int `goqa:"name=hidden"`
+	}
+	err = BindParams(&bad, &Parameters{})
+	for _, want := range []string{
+		"field 'Count': parameter 'Count': can't convert many (string) to an int (default of goqa tag)",
+		"field 'Option': invalid goqa tag option 'bogus'",
+		"field 'hidden': not exported",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("error %v doesn't contain %q", err, want)
+		}
+	}
+	_ = bad.hidden
+
+	if err := BindParams(target, &params); err == nil {
+		t.Errorf("no error for a struct that isn't a pointer")
+	}
+}
+
+// boundTest has its params bound before Setup()
+type boundTest struct {
+	TestCase
+	Count int `goqa:"name=count,required"`
+	seen  int
+}
+
+func (t *boundTest) Setup() (int, error) {
+	t.seen = t.Count
+	return TcPassed, nil
+}
+
+func TestBindBeforeSetup(t *testing.T) {
+	tm := newTestManager()
+	suite := NewSuite("suite1", tm, Parameters{})
+	params := Parameters{}
+	params.AddParam("count", "3", "")
+	test := &boundTest{}
+	suite.AddTest(test, "bound", params)
+	missing := &boundTest{}
+	suite.AddTest(missing, "missing", Parameters{})
+	tm.AddSuite(suite)
+	tm.report.suiteStarted("suite1", "")
+
+	if result := runTest(tm, "suite1", test); result.Status != TcPassed || test.seen != 3 {
+		t.Errorf("bound test is %s, Setup() saw %d", TcStatusName(result.Status), test.seen)
+	}
+	result := runTest(tm, "suite1", missing)
+	if result.Status != TcSetupError || !strings.Contains(result.StatusMessage, "Unable to bind params") {
+		t.Errorf("test with a missing required param is %s: %s", TcStatusName(result.Status), result.StatusMessage)
+	}
+}
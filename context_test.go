@@ -0,0 +1,169 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// countingTest counts how many times Run() is called
+type countingTest struct {
+	TestCase
+	runs int
+}
+
+func (t *countingTest) Run() (int, error) {
+	t.runs++
+	return t.ReturnFromRun()
+}
+
+// cancellingTest cancels the run from Run() and records its context
+type cancellingTest struct {
+	TestCase
+	cancel context.CancelFunc
+	ctx    context.Context
+}
+
+func (t *cancellingTest) Run() (int, error) {
+	t.cancel()
+	t.ctx = t.Context()
+	return TcPassed, nil
+}
+
+// phaseContextTest records the contexts its phases get. RunContext() waits
+// until TeardownContext() is called, so it times out
+type phaseContextTest struct {
+	TestCase
+	runCtx, afterTeardownCtx context.Context
+	teardownCtx              context.Context
+	teardownErr              error
+	teardown, done           chan bool
+}
+
+func (t *phaseContextTest) SetupContext(ctx context.Context) (int, error) {
+	return TcPassed, nil
+}
+
+func (t *phaseContextTest) RunContext(ctx context.Context) (int, error) {
+	defer close(t.done)
+	t.runCtx = ctx
+	<-t.teardown
+	t.afterTeardownCtx = t.Context()
+	return TcPassed, nil
+}
+
+func (t *phaseContextTest) TeardownContext(ctx context.Context) (int, error) {
+	t.teardownCtx = ctx
+	t.teardownErr = ctx.Err()
+	close(t.teardown)
+	return TcPassed, nil
+}
+
+func hasEvent(events []string, event string) bool {
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRunAllContextCancelled(t *testing.T) {
+	r := &recordingReporter{}
+	tm := NewManager(ioutil.Discard, r, SuiteSerial, TcSerial)
+	first := &countingTest{}
+	suite := NewSuite("suite1", &tm, Parameters{})
+	suite.AddTest(first, "first", Parameters{})
+	suite.AddTest(&countingTest{}, "second", Parameters{})
+	tm.AddSuite(suite)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tm.RunAllContext(ctx)
+
+	if first.runs != 0 {
+		t.Errorf("test ran after the run was cancelled")
+	}
+	for _, want := range []string{
+		"test finished suite1/first Skipped",
+		"test finished suite1/second Skipped",
+		"suite finished suite1 Skipped",
+	} {
+		if !hasEvent(r.events, want) {
+			t.Errorf("no event %q in %q", want, r.events)
+		}
+	}
+	if tm.report.Status != ManagerFailed || tm.report.StatusMessage != "Run cancelled" {
+		t.Errorf("manager is %s: %s", ManagerStatusName(tm.report.Status), tm.report.StatusMessage)
+	}
+}
+
+func TestRunAllContextCancelDuringRun(t *testing.T) {
+	r := &recordingReporter{}
+	tm := NewManager(ioutil.Discard, r, SuiteSerial, TcSerial)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	test := &cancellingTest{cancel: cancel}
+	suite := NewSuite("suite1", &tm, Parameters{})
+	suite.AddTest(test, "cancels", Parameters{})
+	second := &countingTest{}
+	suite.AddTest(second, "second", Parameters{})
+	tm.AddSuite(suite)
+	tm.RunAllContext(ctx)
+
+	if test.ctx == nil || test.ctx.Err() == nil {
+		t.Errorf("context of the running test isn't cancelled")
+	}
+	if second.runs != 0 || !hasEvent(r.events, "test finished suite1/second Skipped") {
+		t.Errorf("test after the cancel wasn't skipped: %q", r.events)
+	}
+	if tm.context() != context.Background() {
+		t.Errorf("manager keeps the context after the run")
+	}
+}
+
+func TestTeardownContext(t *testing.T) {
+	tm := NewManager(ioutil.Discard, nil, SuiteSerial, TcSerial)
+	tm.SetTestTimeout(20 * time.Millisecond)
+	test := &phaseContextTest{teardown: make(chan bool), done: make(chan bool)}
+	test.Init("phases", &tm, Parameters{})
+
+	chReport := make(chan testResult, 1)
+	tm.Run("", test, chReport)
+	if result := <-chReport; result.Status != TcTimedOut {
+		t.Errorf("test is %s: %s, want TimedOut", TcStatusName(result.Status), result.StatusMessage)
+	}
+	<-test.done
+	if test.runCtx.Err() == nil {
+		t.Errorf("context of the timed out RunContext() isn't cancelled")
+	}
+	if test.teardownCtx == test.runCtx || test.teardownErr != nil {
+		t.Errorf("TeardownContext() didn't get its own context, err %v", test.teardownErr)
+	}
+	if test.afterTeardownCtx != test.runCtx {
+		t.Errorf("Context() of the running test changed to the teardown context")
+	}
+}
+
+func TestNewContextTester(t *testing.T) {
+	tm := NewManager(ioutil.Discard, nil, SuiteSerial, TcSerial)
+	test := &countingTest{}
+	test.Init("plain", &tm, Parameters{})
+	ct := NewContextTester(test)
+	if _, err := ct.RunContext(context.Background()); err != nil || test.runs != 1 {
+		t.Errorf("RunContext of an adapted test ran Run() %d times, err %v", test.runs, err)
+	}
+
+	phases := &phaseContextTest{}
+	if NewContextTester(phases) != ContextTester(phases) {
+		t.Errorf("a ContextTester is adapted again")
+	}
+	if (&TestCase{}).Context() == nil {
+		t.Errorf("Context() of a test that isn't running is nil")
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParamFromData is the ParamSource kind of the columns of a data file row.
+// The location is the file and row, like "cases.csv:3"
+const ParamFromData = "data"
+
+// Data file formats read by ReadDataFile()
+const (
+	DataFormatCSV   = "csv"
+	DataFormatTSV   = "tsv"
+	DataFormatJSONL = "jsonl"
+)
+
+// DataFormat returns the data file format for the extension of fileName.
+// ".tsv" is TSV, ".jsonl" and ".ndjson" are JSON lines, and anything
+// else is CSV
+func DataFormat(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".tsv":
+		return DataFormatTSV
+	case ".jsonl", ".ndjson":
+		return DataFormatJSONL
+	}
+	return DataFormatCSV
+}
+
+// XMLData runs a test case once for each row of a data file:
+//
+//	<TestCase name="lookup" class="apiTest">
+//		<Data file="lookup.csv"/>
+//	</TestCase>
+//
+// A relative file is in the directory of the test plan. Format is one of
+// the DataFormat* formats, by default from the file extension
+type XMLData struct {
+	File   string `xml:"file,attr" json:"file" yaml:"file"`
+	Format string `xml:"format,attr,omitempty" json:"format,omitempty" yaml:"format,omitempty"`
+}
+
+// DataRow is one row of a data file with the values of its columns
+type DataRow struct {
+	File   string
+	Row    int // line of the row in File, starting at 1
+	Values []MatrixValue
+}
+
+// Location returns the file and row, like "cases.csv:3"
+func (r DataRow) Location() string {
+	return fmt.Sprintf("%s:%d", r.File, r.Row)
+}
+
+// ReadDataFile reads the rows of a data file in format, or the format of
+// its extension when format is empty.
+//
+// The first line of a CSV or TSV file names the columns. A column is a
+// string param, or has a param type after ":", like "timeout:duration" or
+// "codes:list[int]". An empty cell doesn't set the param, so the test
+// keeps the suite or manager value. Each line of a JSON lines file is an
+// object of param names and values of any JSON type
+func ReadDataFile(fileName, format string) ([]DataRow, error) {
+	if format == "" {
+		format = DataFormat(fileName)
+	}
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch format {
+	case DataFormatCSV:
+		return readCSVRows(f, fileName, ',')
+	case DataFormatTSV:
+		return readCSVRows(f, fileName, '\t')
+	case DataFormatJSONL:
+		return readJSONRows(f, fileName)
+	}
+	return nil, fmt.Errorf("unknown data file format '%s'", format)
+}
+
+func readCSVRows(r io.Reader, fileName string, comma rune) ([]DataRow, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	reader.LazyQuotes = comma == '\t'
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%s: no header line", fileName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", fileName, err.Error())
+	}
+
+	names := make([]string, len(header))
+	types := make([]string, len(header))
+	for i, column := range header {
+		names[i] = strings.TrimSpace(column)
+		if n := strings.LastIndex(names[i], ":"); n >= 0 {
+			names[i], types[i] = strings.TrimSpace(names[i][:n]), strings.TrimSpace(names[i][n+1:])
+		}
+		if names[i] == "" {
+			return nil, fmt.Errorf("%s:1: column %d has no name", fileName, i+1)
+		}
+	}
+
+	rows := []DataRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", fileName, err.Error())
+		}
+		line, _ := reader.FieldPos(0)
+		row := DataRow{File: fileName, Row: line}
+		for i, cell := range record {
+			if cell == "" {
+				continue
+			}
+			value, err := parseParamValue(cell, types[i])
+			if err != nil {
+				return nil, fmt.Errorf("%s: column '%s': %s", row.Location(), names[i], err.Error())
+			}
+			row.Values = append(row.Values, MatrixValue{Name: names[i], Value: value})
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func readJSONRows(r io.Reader, fileName string) ([]DataRow, error) {
+	rows := []DataRow{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		row := DataRow{File: fileName, Row: line}
+		values := map[string]interface{}{}
+		if err := decodeJSONNumbers(text, &values); err != nil {
+			return nil, fmt.Errorf("%s: %s", row.Location(), err.Error())
+		}
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			row.Values = append(row.Values, MatrixValue{Name: name, Value: jsonValue(values[name])})
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %s", fileName, err.Error())
+	}
+	return rows, nil
+}
+
+// jsonValue converts the json.Number values in value to int64, or float64
+// when they have a fraction or exponent
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = jsonValue(v[i])
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = jsonValue(v[key])
+		}
+	}
+	return value
+}
+
+// DataTestName returns the name of the test generated from test name for
+// row, like "lookup(cases.csv:3)"
+func DataTestName(name string, row DataRow) string {
+	return fmt.Sprintf("%s(%s:%d)", name, filepath.Base(row.File), row.Row)
+}
+
+// dataRowOf returns the data file and row a test was generated from, or
+// false for a test without data file
+func dataRowOf(params *Parameters) (file string, row int, ok bool) {
+	for _, name := range params.Names() {
+		param, _ := params.GetParam(name)
+		if source := param.Source(); source.Kind == ParamFromData {
+			n := strings.LastIndex(source.Location, ":")
+			if n < 0 {
+				continue
+			}
+			if row, err := strconv.Atoi(source.Location[n+1:]); err == nil {
+				return source.Location[:n], row, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// AddDataTest adds a test created by newTest for each row of the data file
+// fileName, see ReadDataFile(), named by DataTestName(). The columns of a
+// row replace params with the same name. Dependencies on name, see
+// AddDependency(), are on all the generated tests
+func (s *DefaultSuite) AddDataTest(newTest func() Tester, name string, params Parameters, fileName string) error {
+	rows, err := ReadDataFile(fileName, "")
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		testName := DataTestName(name, row)
+		source := ParamSource{Kind: ParamFromData, Location: row.Location()}
+		s.AddTest(newTest(), testName, *matrixParams(row.Values, source, &params))
+		s.addMatrixTest(name, testName)
+	}
+	return nil
+}
+
+// ---------------------------  Generated tests of test plans -------------------
+
+// generatedTest is a test generated from the matrix or data file of a
+// test case, with the values that are added to its params from source
+type generatedTest struct {
+	name   string
+	values []MatrixValue
+	source ParamSource
+}
+
+// generatedTests returns the tests generated from xmlTest at location,
+// or nil for a test case without matrix or data file
+func (p *XMLTestPlan) generatedTests(xmlTest XMLTestCase, location string) ([]generatedTest, PlanErrors) {
+	switch {
+	case xmlTest.Matrix != nil && xmlTest.Data != nil:
+		return nil, PlanErrors{{Location: location, Message: "test case has both a matrix and a data file"}}
+
+	case xmlTest.Matrix != nil:
+		matrixLocation := location + "/Matrix"
+		matrix, errs := xmlTest.Matrix.matrix(matrixLocation)
+		tests := []generatedTest{}
+		for _, values := range matrix.Expand() {
+			source := ParamSource{Kind: ParamFromMatrix, Location: matrixLocation}
+			tests = append(tests, generatedTest{MatrixTestName(xmlTest.Name, values), values, source})
+		}
+		return tests, errs
+
+	case xmlTest.Data != nil:
+		rows, err := ReadDataFile(p.dataFile(xmlTest.Data.File), xmlTest.Data.Format)
+		if err != nil {
+			return nil, PlanErrors{{Location: location + "/Data", Message: err.Error()}}
+		}
+		if len(rows) == 0 {
+			return nil, PlanErrors{{Location: location + "/Data", Message: "data file has no rows"}}
+		}
+		tests := make([]generatedTest, 0, len(rows))
+		for _, row := range rows {
+			source := ParamSource{Kind: ParamFromData, Location: row.Location()}
+			tests = append(tests, generatedTest{DataTestName(xmlTest.Name, row), row.Values, source})
+		}
+		return tests, nil
+	}
+	return nil, nil
+}
+
+// dataFile returns the path of a data file, relative to the directory of
+// the plan file
+func (p *XMLTestPlan) dataFile(fileName string) string {
+	if filepath.IsAbs(fileName) || p.file == "" {
+		return fileName
+	}
+	return filepath.Join(filepath.Dir(p.file), fileName)
+}
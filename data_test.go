@@ -0,0 +1,145 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeDataFiles writes files, by name, to a new directory and returns it
+func writeDataFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadDataFile(t *testing.T) {
+	dir := writeDataFiles(t, map[string]string{
+		"cases.csv":   "host, timeout:duration, codes:list[int]\nalpha,1s,\"200,204\"\nbeta,,404\n",
+		"cases.tsv":   "host\ttimeout:duration\tcodes:list[int]\nalpha\t1s\t200,204\nbeta\t\t404\n",
+		"cases.jsonl": "{\"host\": \"alpha\", \"timeout\": \"1s\", \"codes\": [200, 204]}\n\n{\"host\": \"beta\", \"codes\": [404], \"ratio\": 0.5}\n",
+	})
+	want := map[string][][]MatrixValue{
+		"cases.csv": {
+			{{"host", "alpha"}, {"timeout", time.Second}, {"codes", []int64{200, 204}}},
+			{{"host", "beta"}, {"codes", []int64{404}}},
+		},
+		"cases.jsonl": {
+			{{"codes", []interface{}{int64(200), int64(204)}}, {"host", "alpha"}, {"timeout", "1s"}},
+			{{"codes", []interface{}{int64(404)}}, {"host", "beta"}, {"ratio", 0.5}},
+		},
+	}
+	want["cases.tsv"] = want["cases.csv"]
+	lines := map[string][]int{"cases.csv": {2, 3}, "cases.tsv": {2, 3}, "cases.jsonl": {1, 3}}
+
+	for name, wantRows := range want {
+		rows, err := ReadDataFile(filepath.Join(dir, name), "")
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if len(rows) != len(wantRows) {
+			t.Errorf("%s has %d rows, want %d", name, len(rows), len(wantRows))
+			continue
+		}
+		for i, row := range rows {
+			if !reflect.DeepEqual(row.Values, wantRows[i]) || row.Row != lines[name][i] {
+				t.Errorf("%s row %d at line %d: %v, want %v at line %d", name, i, row.Row, row.Values, wantRows[i], lines[name][i])
+			}
+		}
+	}
+
+	if rows, err := ReadDataFile(filepath.Join(dir, "cases.csv"), DataFormatJSONL); err == nil {
+		t.Errorf("CSV read as JSON lines: %v", rows)
+	}
+}
+
+func TestReadDataFileErrors(t *testing.T) {
+	dir := writeDataFiles(t, map[string]string{
+		"empty.csv":    "",
+		"noname.csv":   "host,:int\na,1\n",
+		"badint.csv":   "host,port:int\na,80\nb,http\n",
+		"broken.jsonl": "{\"host\": \"a\"}\n{host\n",
+	})
+	for name, want := range map[string]string{
+		"empty.csv":    "empty.csv: no header line",
+		"noname.csv":   "noname.csv:1: column 2 has no name",
+		"badint.csv":   "badint.csv:3: column 'port': invalid int value 'http'",
+		"broken.jsonl": "broken.jsonl:2: ",
+		"missing.csv":  "no such file",
+	} {
+		if _, err := ReadDataFile(filepath.Join(dir, name), ""); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: want error %q, got %v", name, want, err)
+		}
+	}
+	if _, err := ReadDataFile(filepath.Join(dir, "badint.csv"), "xls"); err == nil || err.Error() != "unknown data file format 'xls'" {
+		t.Errorf("want an unknown format error, got %v", err)
+	}
+}
+
+func TestPlanDataTests(t *testing.T) {
+	dir := writeDataFiles(t, map[string]string{
+		"lookup.csv": "host,port:int\nalpha,80\nbeta,\n",
+		"plan.xml": `
+<TestManager name="Manager">
+  <TestSuite name="suite1">
+    <Param name="port" type="int">443</Param>
+    <TestCase name="lookup" class="counting">
+      <Data file="lookup.csv"/>
+    </TestCase>
+    <TestCase name="report" class="counting" dependsOn="lookup"/>
+  </TestSuite>
+</TestManager>`,
+	})
+	tm := newTestManager()
+	var plan XMLTestPlan
+	if err := tm.ParseTestPlan(filepath.Join(dir, "plan.xml"), &plan); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.AddTestPlan(&plan, validateRegister()); err != nil {
+		t.Fatal(err)
+	}
+	suite := tm.GetSuite("suite1")
+	if names := testNames(suite); !reflect.DeepEqual(names, []string{"lookup(lookup.csv:2)", "lookup(lookup.csv:3)", "report"}) {
+		t.Errorf("tests %q", names)
+	}
+	params := planTestParams(tm, "suite1", "lookup(lookup.csv:3)")
+	if port, _ := params.GetInt("port"); port != 443 {
+		t.Errorf("empty cell doesn't keep the suite value: port %d", port)
+	}
+	host, _ := params.GetParam("host")
+	if file, row, ok := dataRowOf(params); !ok || filepath.Base(file) != "lookup.csv" || row != 3 || host.Source().Kind != ParamFromData {
+		t.Errorf("generated test is from %s:%d (%t), host from %s", file, row, ok, host.Source())
+	}
+	if tests := suite.(matrixSuite).MatrixTests("lookup"); len(tests) != 2 {
+		t.Errorf("report depends on %q", tests)
+	}
+
+	invalid := parseXMLPlan(t, `
+<TestManager name="Manager">
+  <TestSuite name="suite1">
+    <TestCase name="lookup" class="counting"><Data file="`+filepath.Join(dir, "none.csv")+`"/></TestCase>
+    <TestCase name="both" class="counting"><Data file="lookup.csv"/><Matrix><Axis name="a">1</Axis></Matrix></TestCase>
+  </TestSuite>
+</TestManager>`)
+	err := newTestManager().ValidateTestPlan(invalid, validateRegister())
+	for _, want := range []string{
+		"TestCase[@name='lookup']/Data: open ",
+		"TestCase[@name='both']: test case has both a matrix and a data file",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("no error %q in %v", want, err)
+		}
+	}
+}
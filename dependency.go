@@ -0,0 +1,237 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// dependencySuite is implemented by suites, like DefaultSuite, whose tests
+// can depend on other tests in the same suite
+type dependencySuite interface {
+	AddDependency(testName string, dependsOn ...string)
+	Dependencies(testName string) []string
+}
+
+// AddDependency makes test testName wait for the tests in dependsOn to pass
+// before it runs. When one of them doesn't pass, testName is reported as
+// TcSkipped. Dependencies are checked for unknown tests and cycles before the run
+func (s *DefaultSuite) AddDependency(testName string, dependsOn ...string) {
+	if s.dependencies == nil {
+		s.dependencies = map[string][]string{}
+	}
+	s.dependencies[testName] = append(s.dependencies[testName], dependsOn...)
+}
+
+// Dependencies returns the names of the tests testName depends on
+func (s *DefaultSuite) Dependencies(testName string) []string {
+	return s.dependencies[testName]
+}
+
+// testGraph holds the tests of a suite with their dependencies as indexes
+// into tests. Tests without dependencies on each other keep the suite order
+type testGraph struct {
+	tests      []Tester
+	dependsOn  [][]int  // tests that tests[i] waits for
+	dependents [][]int  // tests that wait for tests[i]
+	skip       []string // reason to skip tests[i] without running it, like a filter
+}
+
+// newTestGraph creates the testGraph for suite.
+// error is returned for dependencies on unknown tests and for cycles
+func newTestGraph(suite Suite) (*testGraph, error) {
+	g := &testGraph{tests: suite.GetTestCases()}
+	g.dependsOn = make([][]int, len(g.tests))
+	g.dependents = make([][]int, len(g.tests))
+	g.skip = make([]string, len(g.tests))
+
+	ds, ok := suite.(dependencySuite)
+	if !ok {
+		return g, nil
+	}
+
+	index := map[string][]int{}
+	for i, tc := range g.tests {
+		index[tc.Name()] = append(index[tc.Name()], i)
+	}
+	if ms, ok := suite.(matrixSuite); ok {
+		// a dependency on a matrix or data test is on every test generated from it
+		for _, tc := range g.tests {
+			for _, name := range ds.Dependencies(tc.Name()) {
+				if _, found := index[name]; found {
+					continue
+				}
+				for _, testName := range ms.MatrixTests(name) {
+					index[name] = append(index[name], index[testName]...)
+				}
+			}
+		}
+	}
+	for i, tc := range g.tests {
+		for _, name := range ds.Dependencies(tc.Name()) {
+			deps, found := index[name]
+			if !found {
+				return nil, fmt.Errorf("suite '%s': test '%s' depends on unknown test '%s'", suite.Name(), tc.Name(), name)
+			}
+			for _, d := range deps {
+				g.dependsOn[i] = append(g.dependsOn[i], d)
+				g.dependents[d] = append(g.dependents[d], i)
+			}
+		}
+	}
+
+	if cycle := g.findCycle(); cycle != nil {
+		return nil, fmt.Errorf("suite '%s': dependency cycle %s", suite.Name(), strings.Join(cycle, " -> "))
+	}
+	return g, nil
+}
+
+// findCycle returns the test names of a dependency cycle, starting and
+// ending with the same test, or nil if there is none
+func (g *testGraph) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(g.tests))
+	path := []int{}
+
+	var visit func(i int) []string
+	visit = func(i int) []string {
+		state[i] = visiting
+		path = append(path, i)
+		for _, d := range g.dependsOn[i] {
+			switch state[d] {
+			case visiting:
+				cycle := []string{}
+				for p := len(path) - 1; p >= 0; p-- {
+					cycle = append(cycle, g.tests[path[p]].Name())
+					if path[p] == d {
+						break
+					}
+				}
+				// path is followed backwards, so reverse to read as "a depends on b"
+				for l, r := 0, len(cycle)-1; l < r; l, r = l+1, r-1 {
+					cycle[l], cycle[r] = cycle[r], cycle[l]
+				}
+				return append(cycle, g.tests[d].Name())
+			case unvisited:
+				if cycle := visit(d); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		return nil
+	}
+
+	for i := range g.tests {
+		if state[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateDependencies checks the test dependencies of all suites and
+// returns the first unknown test or cycle found. RunAll() calls it before
+// any suite runs
+func (tm *TestManager) ValidateDependencies() error {
+	for _, suite := range tm.suites {
+		if _, err := newTestGraph(suite); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// testDone is sent by a test launched by runTests() when it finishes
+type testDone struct {
+	index  int
+	status int
+}
+
+// runTests runs the tests of graph in dependency order, with at most
+// tm.testFlags tests running at the same time. Other values below 1 than
+// TcAll run the tests one at a time like TcSerial. A test whose dependencies
+// didn't all pass is reported as TcSkipped without running
+func (tm *TestManager) runTests(suiteName string, g *testGraph, chReport chan testResult) {
+	limit := tm.testFlags
+	switch {
+	case limit == TcAll:
+		limit = len(g.tests)
+	case limit < 1:
+		limit = 1
+	}
+
+	waiting := make([]int, len(g.tests))
+	blocked := make([]string, len(g.tests))
+	ready := []int{}
+	for i := range g.tests {
+		waiting[i] = len(g.dependsOn[i])
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	done := make(chan testDone, len(g.tests))
+	running := 0
+
+	// finish releases the dependents of test i once it ended with status
+	finish := func(i, status int) {
+		for _, d := range g.dependents[i] {
+			if status != TcPassed && status != TcFlaky && blocked[d] == "" {
+				blocked[d] = fmt.Sprintf("Depends on test '%s' which did not pass (%s)", g.tests[i].Name(), TcStatusName(status))
+			}
+			waiting[d]--
+			if waiting[d] == 0 {
+				ready = append(ready, d)
+				sort.Ints(ready)
+			}
+		}
+	}
+
+	for len(ready) > 0 || running > 0 {
+		for len(ready) > 0 && running < limit {
+			i := ready[0]
+			ready = ready[1:]
+			tc := g.tests[i]
+			if tm.context().Err() != nil {
+				// stop scheduling tests once the run is cancelled
+				tm.skipTest(suiteName, tc, "Run cancelled", chReport)
+				finish(i, TcSkipped)
+				continue
+			}
+			if g.skip[i] != "" {
+				tm.skipTest(suiteName, tc, g.skip[i], chReport)
+				finish(i, TcSkipped)
+				continue
+			}
+			if blocked[i] != "" {
+				tm.skipTest(suiteName, tc, blocked[i], chReport)
+				finish(i, TcSkipped)
+				continue
+			}
+			tm.log.LogMessage("Running test '%s'", tc.Name())
+			running++
+			go func(i int, tc Tester) {
+				result := tm.run(suiteName, tc)
+				chReport <- result
+				done <- testDone{index: i, status: result.Status}
+			}(i, tc)
+		}
+		if running > 0 {
+			d := <-done
+			running--
+			finish(d.index, d.status)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// addCountingSuite adds a suite with a countingTest for each name to tm
+func addCountingSuite(tm *TestManager, suiteName string, names ...string) *DefaultSuite {
+	suite := NewSuite(suiteName, tm, Parameters{})
+	for _, name := range names {
+		suite.AddTest(&countingTest{}, name, Parameters{})
+	}
+	tm.AddSuite(suite)
+	return suite
+}
+
+func TestTestGraphErrors(t *testing.T) {
+	tm := NewManager(ioutil.Discard, nil, SuiteSerial, TcSerial)
+	suite := addCountingSuite(&tm, "suite1", "a", "b", "c")
+	suite.AddDependency("a", "b")
+	suite.AddDependency("b", "c")
+	suite.AddDependency("c", "a")
+	_, err := newTestGraph(suite)
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle a -> b -> c -> a") {
+		t.Errorf("want a cycle error, got %v", err)
+	}
+	if err := tm.ValidateDependencies(); err == nil {
+		t.Errorf("ValidateDependencies doesn't find the cycle")
+	}
+
+	suite = NewSuite("suite2", &tm, Parameters{})
+	suite.AddTest(&countingTest{}, "a", Parameters{})
+	suite.AddDependency("a", "missing")
+	_, err = newTestGraph(suite)
+	if err == nil || !strings.Contains(err.Error(), "test 'a' depends on unknown test 'missing'") {
+		t.Errorf("want an unknown test error, got %v", err)
+	}
+
+	suite = NewSuite("suite3", &tm, Parameters{})
+	suite.AddTest(&countingTest{}, "a", Parameters{})
+	suite.AddDependency("a", "a")
+	if _, err = newTestGraph(suite); err == nil || !strings.Contains(err.Error(), "a -> a") {
+		t.Errorf("want a cycle error for a test depending on itself, got %v", err)
+	}
+}
+
+func TestDependencyOrder(t *testing.T) {
+	r := &recordingReporter{}
+	tm := NewManager(ioutil.Discard, r, SuiteSerial, TcSerial)
+	suite := NewSuite("suite1", &tm, Parameters{})
+	suite.AddTest(&countingTest{}, "report", Parameters{})
+	suite.AddTest(&countingTest{}, "login", Parameters{})
+	suite.AddTest(&countingTest{}, "setup", Parameters{})
+	suite.AddTest(&flakyTest{failures: 100}, "broken", Parameters{})
+	suite.AddTest(&countingTest{}, "after", Parameters{})
+	suite.AddDependency("report", "login")
+	suite.AddDependency("login", "setup")
+	suite.AddDependency("after", "broken", "setup")
+	tm.AddSuite(suite)
+	tm.RunAll()
+
+	started := []string{}
+	for _, event := range r.events {
+		if strings.HasPrefix(event, "test started ") {
+			started = append(started, strings.TrimPrefix(event, "test started suite1/"))
+		}
+	}
+	want := []string{"setup", "login", "report", "broken", "after"}
+	if !reflect.DeepEqual(started, want) {
+		t.Errorf("tests started in order %v, want %v", started, want)
+	}
+	if !hasEvent(r.events, "test finished suite1/after Skipped") {
+		t.Errorf("test depending on a failed test wasn't skipped: %q", r.events)
+	}
+}
+
+func TestDependencyCycleFailsRun(t *testing.T) {
+	tm := NewManager(ioutil.Discard, nil, SuiteSerial, TcSerial)
+	suite := addCountingSuite(&tm, "suite1", "a", "b")
+	suite.AddDependency("a", "b")
+	suite.AddDependency("b", "a")
+	tm.RunAll()
+
+	a := suite.GetTestCase("a").(*countingTest)
+	if a.runs != 0 || tm.report.Status != ManagerFailed {
+		t.Errorf("suite with a cycle ran: %d runs, manager %s", a.runs, ManagerStatusName(tm.report.Status))
+	}
+}
+
+func TestNegativeTestFlagsRunSerial(t *testing.T) {
+	tm := NewManager(ioutil.Discard, nil, SuiteSerial, -5)
+	suite := addCountingSuite(&tm, "suite1", "a", "b")
+
+	done := make(chan bool)
+	go func() {
+		tm.RunAll()
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunAll blocked with test flags below TcAll")
+	}
+	for _, name := range []string{"a", "b"} {
+		if runs := suite.GetTestCase(name).(*countingTest).runs; runs != 1 {
+			t.Errorf("test %s ran %d times", name, runs)
+		}
+	}
+}
@@ -18,12 +18,21 @@ import (
 type Test1 struct {
 	data int
 	goQA.TestCase
+	v1 float64
+	v2 int
+	v3 string
+}
+
+// Setup fails the test when a param is missing or has the wrong type
+func (t *Test1) Setup() (int, error) {
+	t.v1 = t.RequireFloat("val1")
+	t.v2 = t.RequireInt("val2")
+	t.v3 = t.RequireString("val3")
+	return goQA.TcPassed, nil
 }
 
 func (t *Test1) Run() (int, error) {
-	v1 := t.InitParam("val1", 0.0).(float64)
-	v2 := t.InitParam("val2", 0).(int64)
-	v3 := t.InitParam("val3", "").(string)
+	v1, v2, v3 := t.v1, t.v2, t.v3
 	os := t.InitParam("OS", "Unknown").(string)
 	domain := t.InitParam("Domain", "Unknown")
 	suiteMaxTime := t.InitParam("SuiteMaxTime", int64(0)).(int64)
@@ -44,15 +53,17 @@ func (t *Test1) Run() (int, error) {
 	return t.ReturnFromRun()
 }
 
+// Test2 gets its params in fields bound by TestManager before Setup()
 type Test2 struct {
 	data int
 	goQA.TestCase
+	V1 float64 `goqa:"name=val1,required"`
+	V2 int64   `goqa:"name=val2,required"`
+	V3 string  `goqa:"name=val3,default=hello there"`
 }
 
 func (t *Test2) Run() (int, error) {
-	v1 := t.InitParam("val1", 0.0).(float64)
-	v2 := t.InitParam("val2", 0).(int64)
-	v3 := t.InitParam("val3", "").(string)
+	v1, v2, v3 := t.V1, t.V2, t.V3
 
 	t.Verify(v1 == 111.111, "verify val1", "Expected 111.111 but got %f instead", v1)
 	t.Verify(v2 == 550, "verify val2", "Expected 550 but got %d instead", v2)
@@ -83,6 +94,25 @@ var regTests = map[string]reflect.Type{
 	"test2": reflect.TypeOf(Test2{}),
 	"test3": reflect.TypeOf(Test3{})}
 
+// TimedSuite is a user defined suite created for <TestSuite class="TimedSuite">.
+// It gets the params of its <TestSuite> element in Init()
+type TimedSuite struct {
+	goQA.DefaultSuite
+}
+
+// Setup fails the suite when it has no SuiteMaxTime param
+func (s *TimedSuite) Setup() (status int, msg string, err error) {
+	maxTime, err := s.GetParams().GetInt64("SuiteMaxTime")
+	if err != nil {
+		return goQA.SuiteSetupFailed, err.Error(), nil
+	}
+	s.LogMessage("SUITE(%s) Setup with SuiteMaxTime %d", s.Name(), maxTime)
+	return goQA.SuiteOk, "", nil
+}
+
+var regSuites = map[string]reflect.Type{
+	"TimedSuite": reflect.TypeOf(TimedSuite{})}
+
 func main() {
 
 	startTime := time.Now()
@@ -109,7 +139,7 @@ func main() {
 
 	tm.AddLogger("console", logger.LogLevelAll, console)
 
-	reg := goQA.DefaultRegister{Registry: regTests}
+	reg := goQA.DefaultRegister{Registry: regTests, Suites: regSuites}
 
 	tm.RunFromXML("examples\\ExampleTestPlan.xml", &reg)
 
@@ -0,0 +1,366 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// classRegister is implemented by a TestRegister, like DefaultRegister, that
+// can name the class of the tests and suites it creates
+type classRegister interface {
+	TestClass(test Tester) (string, bool)
+	SuiteClass(suite Suite) (string, bool)
+}
+
+// TestClass returns the name test is registered as in Registry. When a
+// type is registered more than once, the first name in sorted order is used
+func (r *DefaultRegister) TestClass(test Tester) (string, bool) {
+	return registeredClass(r.Registry, test)
+}
+
+// SuiteClass returns the name suite is registered as in Suites, or
+// "DefaultSuite" for a DefaultSuite that isn't registered
+func (r *DefaultRegister) SuiteClass(suite Suite) (string, bool) {
+	if class, ok := registeredClass(r.Suites, suite); ok {
+		return class, true
+	}
+	if _, ok := suite.(*DefaultSuite); ok {
+		return "DefaultSuite", true
+	}
+	return "", false
+}
+
+// registeredClass returns the name of the type of v in registry
+func registeredClass(registry map[string]reflect.Type, v interface{}) (string, bool) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	names := []string{}
+	for name, registered := range registry {
+		if registered == t {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", false
+	}
+	sort.Strings(names)
+	return names[0], true
+}
+
+// ExportTestPlan returns the suites and tests added to the manager as a test
+// plan, the inverse of AddTestPlan(). Class names are looked up in registry,
+// which has to be a DefaultRegister or have the same TestClass() and
+// SuiteClass() methods.
+//
+// Params are written with their type and comment. Params set by a manager
+// or suite element of a plan go to the manager or suite, all others are
+// written as test params with their effective value. Int and float params
+// of another Go type than int64 and float64 record it in goType, and the
+// defaults of InitParam(), like failureThreshold, are left out as the tests
+// set them again. The tags and dependencies of the tests are kept. Tests
+// generated from a matrix or data file are written as separate tests, with
+// dependencies on them on each generated test. Manager hooks are not written
+func (tm *TestManager) ExportTestPlan(registry TestRegister) (*XMLTestPlan, error) {
+	classes, ok := registry.(classRegister)
+	if !ok {
+		return nil, fmt.Errorf("registry %T can't name test and suite classes", registry)
+	}
+
+	testPlan := &XMLTestPlan{Name: "Manager"}
+	var errs []string
+	mngrParams := &exportedParams{}
+	for _, suite := range tm.suites {
+		xmlSuite := XMLTestSuite{Name: suite.Name()}
+		if class, ok := classes.SuiteClass(suite); ok {
+			xmlSuite.Class = class
+		} else {
+			errs = append(errs, fmt.Sprintf("suite '%s': class of %T is not registered", suite.Name(), suite))
+		}
+		if ts, ok := suite.(tagSuite); ok {
+			xmlSuite.Tags = strings.Join(ts.Tags(), ",")
+		}
+
+		suiteParams := &exportedParams{}
+		for _, test := range suite.GetTestCases() {
+			xmlTest := XMLTestCase{Name: test.Name()}
+			if class, ok := classes.TestClass(test); ok {
+				xmlTest.Class = class
+			} else {
+				errs = append(errs, fmt.Sprintf("test '%s' of suite '%s': class of %T is not registered", test.Name(), suite.Name(), test))
+			}
+			if ts, ok := suite.(tagSuite); ok {
+				xmlTest.Tags = strings.Join(ts.TestTags(test.Name()), ",")
+			}
+			if ds, ok := suite.(dependencySuite); ok {
+				xmlTest.DependsOn = strings.Join(exportedDependencies(suite, ds.Dependencies(test.Name())), ",")
+			}
+
+			if p, ok := test.(paramHolder); ok {
+				testParams := &exportedParams{}
+				params := p.GetParams()
+				for _, name := range params.Names() {
+					param, _ := params.GetParam(name)
+					for _, err := range exportParam(param, mngrParams, suiteParams, testParams) {
+						errs = append(errs, fmt.Sprintf("test '%s' of suite '%s': %s", test.Name(), suite.Name(), err))
+					}
+				}
+				xmlTest.Params = testParams.params
+			}
+			xmlSuite.TestCases = append(xmlSuite.TestCases, xmlTest)
+		}
+		xmlSuite.Params = suiteParams.params
+		testPlan.Suites = append(testPlan.Suites, xmlSuite)
+	}
+	testPlan.Params = mngrParams.params
+
+	if len(errs) > 0 {
+		return testPlan, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return testPlan, nil
+}
+
+// exportedDependencies returns dependsOn with the matrix and data tests
+// replaced by the tests generated from them, which are exported by name
+func exportedDependencies(suite Suite, dependsOn []string) []string {
+	ms, ok := suite.(matrixSuite)
+	if !ok {
+		return dependsOn
+	}
+	names := []string{}
+	for _, name := range dependsOn {
+		if generated := ms.MatrixTests(name); len(generated) > 0 && suite.GetTestCase(name) == nil {
+			names = append(names, generated...)
+		} else {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// exportedParams collects the params of one plan element once
+type exportedParams struct {
+	params []XMLParam
+	names  map[string]bool
+}
+
+func (e *exportedParams) add(param XMLParam) {
+	if e.names == nil {
+		e.names = map[string]bool{}
+	}
+	if !e.names[param.Name] {
+		e.names[param.Name] = true
+		e.params = append(e.params, param)
+	}
+}
+
+// exportParam adds param to the element it was set by. The manager and
+// suite params it replaced are added too, so they stay in the plan
+func exportParam(param Parameter, mngrParams, suiteParams, testParams *exportedParams) []string {
+	var errs []string
+	for i, p := range append([]Parameter{param}, param.Overrides()...) {
+		target := testParams
+		switch p.Source().Kind {
+		case ParamFromManager:
+			target = mngrParams
+		case ParamFromSuite:
+			target = suiteParams
+		case ParamFromDefault:
+			continue
+		default:
+			if i > 0 {
+				// only the effective value of the test is kept
+				continue
+			}
+		}
+		xmlParam, err := newXMLParam(p.Name(), p.Value(), p.Comment())
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		target.add(xmlParam)
+	}
+	return errs
+}
+
+// newXMLParam returns the XMLParam that AddTestPlan() converts to value.
+// A "${" in the value is escaped so it isn't read as a reference
+func newXMLParam(name string, value interface{}, comment string) (XMLParam, error) {
+	paramType, err := paramTypeOf(value)
+	if err != nil {
+		return XMLParam{}, fmt.Errorf("param '%s': %s", name, err.Error())
+	}
+	text := formatParamValue(value)
+	kind, _ := splitParamType(paramType)
+	switch kind {
+	case "list", "map":
+		// elements are written as strings, so durations and times
+		// are read back in their own format
+		text, err = formatElems(value)
+	case "json":
+		var buf []byte
+		buf, err = json.Marshal(value)
+		text = string(buf)
+	}
+	if err != nil {
+		return XMLParam{}, fmt.Errorf("param '%s': %s", name, err.Error())
+	}
+	return XMLParam{
+		Name:    name,
+		Type:    paramType,
+		Comment: comment,
+		GoType:  paramGoType(value, paramType),
+		Value:   strings.Replace(text, "${", "$${", -1),
+	}, nil
+}
+
+// paramGoType returns the Go type of value when parseParamValue() returns
+// another one for paramType, like "int" for an int read back as int64.
+// Named types other than time.Duration are read back as their kind
+func paramGoType(value interface{}, paramType string) string {
+	var parsed reflect.Type
+	kind, elemType := splitParamType(paramType)
+	switch kind {
+	case "list":
+		parsed = reflect.SliceOf(paramElemTypes[elemType])
+	case "map":
+		parsed = reflect.MapOf(reflect.TypeOf(""), paramElemTypes[elemType])
+	case "int", "float":
+		parsed = paramElemTypes[kind]
+	default:
+		return ""
+	}
+	t := reflect.TypeOf(value)
+	if t == parsed {
+		return ""
+	}
+	if _, err := goParamType(t.String()); err != nil {
+		return ""
+	}
+	return t.String()
+}
+
+// formatElems returns a list or map as a JSON array or object of the
+// elements formatted by formatParamValue()
+func formatElems(value interface{}) (string, error) {
+	v := reflect.ValueOf(value)
+	var elems interface{}
+	if v.Kind() == reflect.Map {
+		m := make(map[string]string, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = formatParamValue(iter.Value().Interface())
+		}
+		elems = m
+	} else {
+		list := make([]string, v.Len())
+		for i := range list {
+			list[i] = formatParamValue(v.Index(i).Interface())
+		}
+		elems = list
+	}
+	buf, err := json.Marshal(elems)
+	return string(buf), err
+}
+
+// paramTypeOf returns the param type of a value, see paramTypes
+func paramTypeOf(value interface{}) (string, error) {
+	if value == nil {
+		return "json", nil
+	}
+	t := reflect.TypeOf(value)
+	if name, ok := elemTypeName(t); ok {
+		return name, nil
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if name, ok := elemTypeName(t.Elem()); ok {
+			return fmt.Sprintf("list[%s]", name), nil
+		}
+	case reflect.Map:
+		if name, ok := elemTypeName(t.Elem()); ok && t.Key().Kind() == reflect.String {
+			return fmt.Sprintf("map[%s]", name), nil
+		}
+	}
+	if _, err := json.Marshal(value); err != nil {
+		return "", fmt.Errorf("can't write %v (%T) to a test plan", value, value)
+	}
+	return "json", nil
+}
+
+// elemTypeName returns the param type for the list and map element type t
+func elemTypeName(t reflect.Type) (string, bool) {
+	switch t {
+	case reflect.TypeOf(time.Duration(0)):
+		return "duration", true
+	case reflect.TypeOf(time.Time{}):
+		return "time", true
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int", true
+	case reflect.Float32, reflect.Float64:
+		return "float", true
+	case reflect.String:
+		return "string", true
+	case reflect.Bool:
+		return "bool", true
+	}
+	return "", false
+}
+
+// WriteTestPlan writes testPlan to w in format, one of the PlanFormat*
+// formats, so ParseTestPlan() reads it back
+func WriteTestPlan(w io.Writer, testPlan *XMLTestPlan, format string) error {
+	switch format {
+	case PlanFormatXML:
+		buf, err := xml.MarshalIndent(testPlan, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, buf)
+		return err
+	case PlanFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(testPlan)
+	case PlanFormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(testPlan); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("unknown test plan format '%s'", format)
+}
+
+// ExportPlanFile writes the suites and tests of the manager, see
+// ExportTestPlan(), to fileName in the format of its extension
+func (tm *TestManager) ExportPlanFile(fileName string, registry TestRegister) error {
+	testPlan, err := tm.ExportTestPlan(registry)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := WriteTestPlan(&buf, testPlan, PlanFormat(fileName)); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, buf.Bytes(), 0644)
+}
@@ -0,0 +1,111 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportTestPlan(t *testing.T) {
+	tm := newTestManager()
+	suite := NewSuite("suite1", tm, Parameters{})
+	params := Parameters{}
+	params.AddParam("count", 3, "number of runs")
+	params.AddParam("ratio", float32(0.5), "")
+	params.AddParam("codes", []int{200, 204}, "")
+	params.AddParam("limits", map[string]uint8{"low": 1}, "")
+	params.AddParam("timeout", time.Second, "")
+	params.AddParam("path", "${HOME}/bin", "")
+	suite.AddTest(&countingTest{}, "lookup", params)
+	matrix := Matrix{Axes: []MatrixAxis{{Name: "size", Values: []interface{}{int64(1), int64(2)}}}}
+	suite.AddMatrixTest(func() Tester { return &countingTest{} }, "sweep", Parameters{}, matrix)
+	suite.AddTest(&countingTest{}, "report", Parameters{})
+	suite.AddDependency("report", "sweep", "lookup")
+	tm.AddSuite(suite)
+
+	plan, err := tm.ExportTestPlan(validateRegister())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, param := range plan.Suites[0].TestCases[0].Params {
+		if param.Name == "failureThreshold" {
+			t.Errorf("default %+v is exported", param)
+		}
+		if param.Name == "count" && param.GoType != "int" {
+			t.Errorf("count is exported as %+v", param)
+		}
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"plan.xml", "plan.json", "plan.yaml"} {
+		file := filepath.Join(dir, name)
+		if err := tm.ExportPlanFile(file, validateRegister()); err != nil {
+			t.Fatal(err)
+		}
+		imported := newTestManager()
+		var plan XMLTestPlan
+		if err := imported.ParseTestPlan(file, &plan); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if err := imported.AddTestPlan(&plan, validateRegister()); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		got := planTestParams(imported, "suite1", "lookup")
+		for param, want := range map[string]interface{}{
+			"count":   3,
+			"ratio":   float32(0.5),
+			"codes":   []int{200, 204},
+			"limits":  map[string]uint8{"low": 1},
+			"timeout": time.Second,
+			"path":    "${HOME}/bin",
+		} {
+			if value, _ := got.GetParamValue(param); !reflect.DeepEqual(value, want) {
+				t.Errorf("%s: %s is %#v, want %#v", name, param, value, want)
+			}
+		}
+		if threshold, _ := got.GetParam("failureThreshold"); threshold.Source().Kind != ParamFromDefault {
+			t.Errorf("%s: failureThreshold is from %s", name, threshold.Source())
+		}
+		deps := imported.GetSuite("suite1").(dependencySuite).Dependencies("report")
+		if want := []string{"sweep(size=1)", "sweep(size=2)", "lookup"}; !reflect.DeepEqual(deps, want) {
+			t.Errorf("%s: report depends on %q, want %q", name, deps, want)
+		}
+	}
+}
+
+func TestConvertGoType(t *testing.T) {
+	for _, test := range []struct {
+		value  interface{}
+		goType string
+		want   interface{}
+	}{
+		{int64(5), "int", 5},
+		{int64(200), "uint8", uint8(200)},
+		{1.5, "float32", float32(1.5)},
+		{[]int64{1, 2}, "[]int16", []int16{1, 2}},
+		{map[string]float64{"a": 2}, "map[string]int", map[string]int{"a": 2}},
+	} {
+		got, err := convertGoType(test.value, test.goType)
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v as %s is %#v, %v", test.value, test.goType, got, err)
+		}
+	}
+	for _, test := range []struct {
+		value  interface{}
+		goType string
+	}{
+		{int64(300), "uint8"},
+		{int64(5), "complex64"},
+		{"five", "int"},
+	} {
+		if got, err := convertGoType(test.value, test.goType); err == nil {
+			t.Errorf("%v as %s is %#v, want an error", test.value, test.goType, got)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// tagSuite is implemented by suites, like DefaultSuite, that have tags for
+// the suite and its tests
+type tagSuite interface {
+	AddTags(tags ...string)
+	AddTestTags(testName string, tags ...string)
+	Tags() []string
+	TestTags(testName string) []string
+}
+
+// AddTaggedTest is AddTest() with tags for the test
+func (s *DefaultSuite) AddTaggedTest(test Tester, name string, params Parameters, tags ...string) Suite {
+	s.AddTest(test, name, params)
+	s.AddTestTags(name, tags...)
+	return s
+}
+
+// AddTags adds tags to the suite. All tests in the suite have the suite tags
+func (s *DefaultSuite) AddTags(tags ...string) {
+	s.tags = append(s.tags, tags...)
+}
+
+// AddTestTags adds tags to test testName
+func (s *DefaultSuite) AddTestTags(testName string, tags ...string) {
+	if s.testTags == nil {
+		s.testTags = map[string][]string{}
+	}
+	s.testTags[testName] = append(s.testTags[testName], tags...)
+}
+
+// Tags returns the tags of the suite
+func (s *DefaultSuite) Tags() []string {
+	return s.tags
+}
+
+// TestTags returns the tags of test testName without the suite tags
+func (s *DefaultSuite) TestTags(testName string) []string {
+	return s.testTags[testName]
+}
+
+// splitList splits a comma separated list and drops empty items
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// TestFilter selects tests by suite name, test name, and tags, which
+// include the suite tags. String() describes the filter in skip reasons
+type TestFilter interface {
+	Match(suiteName, testName string, tags []string) bool
+	String() string
+}
+
+// Include adds a filter that selects tests to run. When there are include
+// filters, a test has to match one of them to run
+func (tm *TestManager) Include(filter TestFilter) {
+	tm.includes = append(tm.includes, filter)
+}
+
+// Exclude adds a filter for tests not to run. Excluded tests are reported as
+// TcSkipped with the filter as reason, even if an include filter matches them
+func (tm *TestManager) Exclude(filter TestFilter) {
+	tm.excludes = append(tm.excludes, filter)
+}
+
+// ClearFilters removes all include and exclude filters
+func (tm *TestManager) ClearFilters() {
+	tm.includes = nil
+	tm.excludes = nil
+}
+
+// filterReasons returns the reason to skip each test of suite, or "" for
+// the tests selected by the include and exclude filters
+func (tm *TestManager) filterReasons(suite Suite) []string {
+	tests := suite.GetTestCases()
+	reasons := make([]string, len(tests))
+	if len(tm.includes) == 0 && len(tm.excludes) == 0 {
+		return reasons
+	}
+
+	var suiteTags []string
+	ts, hasTags := suite.(tagSuite)
+	if hasTags {
+		suiteTags = ts.Tags()
+	}
+	for i, tc := range tests {
+		tags := suiteTags
+		if hasTags {
+			tags = append(append([]string{}, suiteTags...), ts.TestTags(tc.Name())...)
+		}
+		reasons[i] = tm.filterReason(suite.Name(), tc.Name(), tags)
+	}
+	return reasons
+}
+
+// hasSelected returns true if one of the reasons from filterReasons() is ""
+func hasSelected(reasons []string) bool {
+	for _, reason := range reasons {
+		if reason == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// filterReason returns the reason to skip test testName, or "" when it runs
+func (tm *TestManager) filterReason(suiteName, testName string, tags []string) string {
+	for _, filter := range tm.excludes {
+		if filter.Match(suiteName, testName, tags) {
+			return fmt.Sprintf("Excluded by filter %s", filter)
+		}
+	}
+	if len(tm.includes) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(tm.includes))
+	for _, filter := range tm.includes {
+		if filter.Match(suiteName, testName, tags) {
+			return ""
+		}
+		names = append(names, filter.String())
+	}
+	return fmt.Sprintf("Not included by filters %s", strings.Join(names, ", "))
+}
+
+// ---------------------------  Filters -------------------
+
+// idFilter matches suite names and "suite/test" IDs
+type idFilter struct {
+	ids map[string]bool
+}
+
+// NewIDFilter returns a filter that matches the tests listed in ids. An ID
+// is "suite/test" for one test, or a suite name for all tests of the suite
+func NewIDFilter(ids ...string) TestFilter {
+	f := &idFilter{ids: map[string]bool{}}
+	for _, id := range ids {
+		f.ids[strings.TrimSpace(id)] = true
+	}
+	return f
+}
+
+func (f *idFilter) Match(suiteName, testName string, tags []string) bool {
+	return f.ids[suiteName] || f.ids[suiteName+"/"+testName]
+}
+
+func (f *idFilter) String() string {
+	ids := make([]string, 0, len(f.ids))
+	for id := range f.ids {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return fmt.Sprintf("ids(%s)", strings.Join(ids, ","))
+}
+
+// globFilter matches a path.Match pattern
+type globFilter struct {
+	pattern string
+}
+
+// NewGlobFilter returns a filter that matches shell patterns like "chamber*".
+// A pattern with '/' is matched against "suite/test", like "suite1/test?",
+// and a pattern without '/' against the suite name
+func NewGlobFilter(pattern string) (TestFilter, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob '%s': %s", pattern, err.Error())
+	}
+	return &globFilter{pattern: pattern}, nil
+}
+
+func (f *globFilter) Match(suiteName, testName string, tags []string) bool {
+	name := suiteName
+	if strings.Contains(f.pattern, "/") {
+		name = suiteName + "/" + testName
+	}
+	ok, _ := path.Match(f.pattern, name)
+	return ok
+}
+
+func (f *globFilter) String() string {
+	return fmt.Sprintf("glob(%s)", f.pattern)
+}
+
+// regexFilter matches a regular expression against "suite/test"
+type regexFilter struct {
+	re *regexp.Regexp
+}
+
+// NewRegexFilter returns a filter that matches the regular expression expr
+// anywhere in "suite/test", like "^suite1/" or "test[0-9]+$"
+func NewRegexFilter(expr string) (TestFilter, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &regexFilter{re: re}, nil
+}
+
+func (f *regexFilter) Match(suiteName, testName string, tags []string) bool {
+	return f.re.MatchString(suiteName + "/" + testName)
+}
+
+func (f *regexFilter) String() string {
+	return fmt.Sprintf("regex(%s)", f.re.String())
+}
+
+// tagFilter matches a tag expression
+type tagFilter struct {
+	expr  string
+	match func(tags map[string]bool) bool
+}
+
+// NewTagFilter returns a filter that matches the tags of a test with a tag
+// expression. Tags are combined with "&&" (or "and"), "||" (or "or"),
+// "!" (or "not") and parentheses, like "smoke && !slow" or "chamber or (api and fast)"
+func NewTagFilter(expr string) (TestFilter, error) {
+	p := &tagParser{tokens: tokenizeTags(expr)}
+	match, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected '%s'", p.tokens[p.pos])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag expression '%s': %s", expr, err.Error())
+	}
+	return &tagFilter{expr: expr, match: match}, nil
+}
+
+func (f *tagFilter) Match(suiteName, testName string, tags []string) bool {
+	set := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		set[tag] = true
+	}
+	return f.match(set)
+}
+
+func (f *tagFilter) String() string {
+	return fmt.Sprintf("tags(%s)", f.expr)
+}
+
+// tokenizeTags splits a tag expression into tags, operators and parentheses
+func tokenizeTags(expr string) []string {
+	tokens := []string{}
+	for i := 0; i < len(expr); {
+		c := rune(expr[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')' || c == '!':
+			tokens = append(tokens, string(c))
+			i++
+		case strings.HasPrefix(expr[i:], "&&") || strings.HasPrefix(expr[i:], "||"):
+			tokens = append(tokens, expr[i:i+2])
+			i += 2
+		default:
+			start := i
+			for i < len(expr) && !strings.ContainsRune(" \t\r\n()!&|", rune(expr[i])) {
+				i++
+			}
+			if i == start {
+				// a single '&' or '|'
+				i++
+			}
+			tokens = append(tokens, expr[start:i])
+		}
+	}
+	return tokens
+}
+
+// tagParser parses tag expressions:
+//
+//	or   = and { ("||" | "or") and }
+//	and  = not { ("&&" | "and") not }
+//	not  = ("!" | "not") not | "(" or ")" | tag
+type tagParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *tagParser) next() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *tagParser) parseOr() (func(map[string]bool) bool, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.next() == "||" || strings.EqualFold(p.next(), "or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(tags map[string]bool) bool { return l(tags) || right(tags) }
+	}
+	return left, nil
+}
+
+func (p *tagParser) parseAnd() (func(map[string]bool) bool, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.next() == "&&" || strings.EqualFold(p.next(), "and") {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(tags map[string]bool) bool { return l(tags) && right(tags) }
+	}
+	return left, nil
+}
+
+func (p *tagParser) parseNot() (func(map[string]bool) bool, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("missing tag")
+	case token == "!" || strings.EqualFold(token, "not"):
+		p.pos++
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(tags map[string]bool) bool { return !expr(tags) }, nil
+	case token == "(":
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		return expr, nil
+	case token == ")" || token == "&&" || token == "||" || token == "&" || token == "|" ||
+		strings.EqualFold(token, "and") || strings.EqualFold(token, "or"):
+		return nil, fmt.Errorf("unexpected '%s'", token)
+	}
+	p.pos++
+	return func(tags map[string]bool) bool { return tags[token] }, nil
+}
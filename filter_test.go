@@ -0,0 +1,140 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-QA/logger"
+)
+
+func TestTagFilter(t *testing.T) {
+	tests := []struct {
+		expr  string
+		tags  []string
+		match bool
+	}{
+		{"smoke", []string{"smoke"}, true},
+		{"smoke", []string{"slow"}, false},
+		{"smoke && !slow", []string{"smoke"}, true},
+		{"smoke && !slow", []string{"smoke", "slow"}, false},
+		{"smoke and not slow", []string{"smoke", "slow"}, false},
+		{"chamber or (api and fast)", []string{"api", "fast"}, true},
+		{"chamber || (api && fast)", []string{"api"}, false},
+		{"!(a || b)", []string{}, true},
+		{"a || b && c", []string{"a"}, true},
+	}
+	for _, test := range tests {
+		filter, err := NewTagFilter(test.expr)
+		if err != nil {
+			t.Errorf("%s: %s", test.expr, err)
+			continue
+		}
+		if filter.Match("suite1", "test1", test.tags) != test.match {
+			t.Errorf("%s matches %v: %t, want %t", test.expr, test.tags, !test.match, test.match)
+		}
+	}
+
+	for _, expr := range []string{"", "smoke &&", "(smoke", "smoke)", "a & b", "or smoke", "a b"} {
+		if _, err := NewTagFilter(expr); err == nil {
+			t.Errorf("no error for tag expression %q", expr)
+		}
+	}
+}
+
+func TestNameFilters(t *testing.T) {
+	ids := NewIDFilter("suite1", " suite2/test2 ")
+	for _, test := range []struct {
+		suite, test string
+		match       bool
+	}{
+		{"suite1", "test1", true},
+		{"suite2", "test2", true},
+		{"suite2", "test1", false},
+	} {
+		if ids.Match(test.suite, test.test, nil) != test.match {
+			t.Errorf("%s matches %s/%s: %t", ids, test.suite, test.test, !test.match)
+		}
+	}
+	if ids.String() != "ids(suite1,suite2/test2)" {
+		t.Errorf("String() is %s", ids)
+	}
+
+	glob, _ := NewGlobFilter("chamber*")
+	if !glob.Match("chamberA", "test1", nil) || glob.Match("suite1", "chamber1", nil) {
+		t.Errorf("a glob without '/' doesn't match the suite name")
+	}
+	glob, _ = NewGlobFilter("suite1/test?")
+	if !glob.Match("suite1", "test1", nil) || glob.Match("suite1", "test10", nil) {
+		t.Errorf("a glob with '/' doesn't match suite/test")
+	}
+	if _, err := NewGlobFilter("[a"); err == nil {
+		t.Errorf("no error for an invalid glob")
+	}
+
+	re, _ := NewRegexFilter("test[0-9]+$")
+	if !re.Match("suite1", "test12", nil) || re.Match("suite1", "test1a", nil) {
+		t.Errorf("regex doesn't match suite/test")
+	}
+	if _, err := NewRegexFilter("("); err == nil {
+		t.Errorf("no error for an invalid regex")
+	}
+}
+
+func TestIncludeExclude(t *testing.T) {
+	r := &recordingReporter{}
+	var log bytes.Buffer
+	tm := newTestManager(r, &TextReporter{})
+	tm.AddLogger("test", logger.LogLevelAll, &log)
+	suite := NewSuite("suite1", tm, Parameters{})
+	suite.AddTags("chamber")
+	suite.AddTaggedTest(&countingTest{}, "fast", Parameters{}, "smoke")
+	slow := &countingTest{}
+	suite.AddTaggedTest(slow, "slow", Parameters{}, "smoke", "slow")
+	suite.AddTest(&countingTest{}, "other", Parameters{})
+	tm.AddSuite(suite)
+	other := addCountingSuite(tm, "suite2", "test1")
+
+	smoke, _ := NewTagFilter("smoke")
+	slowTags, _ := NewTagFilter("slow")
+	chamber, _ := NewTagFilter("chamber")
+	tm.Include(smoke)
+	tm.Exclude(slowTags)
+	tm.RunAll()
+
+	for _, want := range []string{
+		"test finished suite1/fast Passed",
+		"test finished suite1/slow Skipped",
+		"test finished suite1/other Skipped",
+		"suite finished suite2 Skipped",
+	} {
+		if !hasEvent(r.events, want) {
+			t.Errorf("no event %q in %q", want, r.events)
+		}
+	}
+	if slow.runs != 0 || other.GetTestCase("test1").(*countingTest).runs != 0 {
+		t.Errorf("tests that aren't selected ran")
+	}
+	if want := "TEST SKIPPED         slow::Excluded by filter tags(slow)"; !strings.Contains(log.String(), want) {
+		t.Errorf("text report doesn't contain %q", want)
+	}
+	reasons := tm.filterReasons(suite)
+	if reasons[0] != "" || reasons[1] != "Excluded by filter tags(slow)" || reasons[2] != "Not included by filters tags(smoke)" {
+		t.Errorf("skip reasons %q", reasons)
+	}
+
+	// suite tags are tags of all tests
+	tm.ClearFilters()
+	tm.Include(chamber)
+	if reasons := tm.filterReasons(suite); reasons[2] != "" {
+		t.Errorf("test isn't selected by the suite tag: %q", reasons[2])
+	}
+	tm.ClearFilters()
+	if reasons := tm.filterReasons(other); !hasSelected(reasons) {
+		t.Errorf("tests aren't selected without filters")
+	}
+}
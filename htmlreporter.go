@@ -0,0 +1,174 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+
+	"github.com/go-QA/logger"
+)
+
+// CSS classes used to colour status codes in HTML reports
+const (
+	htmlStatusPassed  = "passed"
+	htmlStatusFailed  = "failed"
+	htmlStatusError   = "error"
+	htmlStatusSkipped = "skipped"
+	htmlStatusFlaky   = "flaky"
+)
+
+// htmlTcStatusClass returns CSS class for Tc* status codes
+func htmlTcStatusClass(status int) string {
+	switch status {
+	case TcPassed:
+		return htmlStatusPassed
+	case TcFlaky:
+		return htmlStatusFlaky
+	case TcSkipped, TcNotFound:
+		return htmlStatusSkipped
+	case TcFailed, TcCriticalError, TcSetupFailed, TcTeardownFailed:
+		return htmlStatusFailed
+	}
+	return htmlStatusError
+}
+
+// htmlSuiteStatusClass returns CSS class for Suite* status codes
+func htmlSuiteStatusClass(status int) string {
+	switch status {
+	case SuiteOk, SuitePassed:
+		return htmlStatusPassed
+	case SuiteSkipped, SuiteNotFound:
+		return htmlStatusSkipped
+	case SuiteFailed, SuiteSetupFailed, SuiteTeardownFailed:
+		return htmlStatusFailed
+	}
+	return htmlStatusError
+}
+
+// htmlManagerStatusClass returns CSS class for Manager* status codes
+func htmlManagerStatusClass(status int) string {
+	if status == ManagerPassed {
+		return htmlStatusPassed
+	}
+	return htmlStatusFailed
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"tcClass":      htmlTcStatusClass,
+	"suiteClass":   htmlSuiteStatusClass,
+	"managerClass": htmlManagerStatusClass,
+	"seconds":      func(sec float64) string { return fmt.Sprintf("%.2f", sec) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Test Run Report '{{.Name}}'</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin: 0.5em 0 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #eee; }
+summary { cursor: pointer; font-size: 1.1em; padding: 0.3em 0; }
+pre { margin: 0; white-space: pre-wrap; font-size: 0.9em; }
+table.params { margin: 0; font-size: 0.9em; }
+.override { color: #777; }
+.badge { display: inline-block; padding: 0.1em 0.5em; border-radius: 0.3em; color: #fff; font-size: 0.9em; }
+.badge.passed { background: #2e7d32; }
+.badge.failed { background: #c62828; }
+.badge.error { background: #6a1b9a; }
+.badge.skipped { background: #9e9e9e; }
+.badge.flaky { background: #ef6c00; }
+details.suite { border-left: 0.4em solid #ccc; padding-left: 0.8em; margin-bottom: 0.5em; }
+details.suite.passed { border-color: #2e7d32; }
+details.suite.failed { border-color: #c62828; }
+details.suite.error { border-color: #6a1b9a; }
+details.suite.skipped { border-color: #9e9e9e; }
+</style>
+</head>
+<body>
+<h1>Test Run Report '{{.Name}}'</h1>
+<p>{{.Start.Format "2006-01-02 15:04:05"}} ({{seconds .Duration}} sec) <span class="badge {{managerClass .Status}}">{{.StatusName}}</span> {{.StatusMessage}}</p>
+
+<h2>Summary</h2>
+{{with .Statistics}}
+<table>
+<tr><th></th><th>Total</th><th>Passed</th><th>Failed</th><th>Error</th><th>SetUp failed</th><th>SetUp error</th><th>TearDown failed</th><th>TearDown error</th><th>Not Found</th><th>Skipped</th><th>Timed out</th><th>Flaky</th></tr>
+<tr><th>Suites</th><td>{{.NumberOfTestSuites}}</td><td>{{.NumberOfTestSuitesPassed}}</td><td>{{.NumberOfTestSuitesFailed}}</td><td>{{.NumberOfTestSuitesError}}</td><td>{{.NumberOfTestSuitesSetUpFailed}}</td><td>{{.NumberOfTestSuitesSetUpError}}</td><td>{{.NumberOfTestSuitesTearDownFailed}}</td><td>{{.NumberOfTestSuitesTearDownError}}</td><td>{{.NumberOfTestSuitesNotFound}}</td><td></td><td></td><td></td></tr>
+<tr><th>Tests</th><td>{{.TotalNumberOfTestCases}}</td><td>{{.TotalNumberOfTestCasesPassed}}</td><td>{{.TotalNumberOfTestCasesFailed}}</td><td>{{.TotalNumberOfTestCasesError}}</td><td>{{.TotalNumberOfTestCasesSetUpFailed}}</td><td>{{.TotalNumberOfTestCasesSetUpError}}</td><td>{{.TotalNumberOfTestCasesTearDownFailed}}</td><td>{{.TotalNumberOfTestCasesTearDownError}}</td><td>{{.TotalNumberOfTestCasesNotFound}}</td><td>{{.TotalNumberOfTestCasesSkipped}}</td><td>{{.TotalNumberOfTestCasesTimedOut}}</td><td>{{.TotalNumberOfTestCasesFlaky}}</td></tr>
+</table>
+{{end}}
+
+<h2>Suites</h2>
+{{range .Suites}}
+<details class="suite {{suiteClass .Status}}"{{if ne (suiteClass .Status) "passed"}} open{{end}}>
+<summary><b>{{.Name}}</b> <span class="badge {{suiteClass .Status}}">{{.StatusName}}</span> {{len .Tests}} tests ({{seconds .Duration}} sec) {{.StatusMessage}}</summary>
+<table>
+<tr><th>Test</th><th>Status</th><th>Time (sec)</th><th>Message</th><th>Parameters</th></tr>
+{{range .Tests}}
+<tr>
+<td>{{.Name}}</td>
+<td><span class="badge {{tcClass .Status}}">{{.StatusName}}</span>{{if gt .Attempts 1}} <i>{{.Attempts}} attempts</i>{{end}}</td>
+<td>{{seconds .Duration}}</td>
+<td>{{.StatusMessage}}{{if and .Output (ne (tcClass .Status) "passed")}}<details><summary>log output</summary><pre>{{.Output}}</pre></details>{{end}}</td>
+<td>{{if .Params}}<table class="params">{{range .Params}}<tr><td><b>{{.Name}}</b></td><td>{{.Value}} <i>({{.Type}})</i>{{if .Comment}} - {{.Comment}}{{end}}</td><td>{{.Source}}{{range .Overrides}}<div class="override">overrides {{.Value}} from {{.Source}}</div>{{end}}</td></tr>{{end}}</table>{{end}}</td>
+</tr>
+{{end}}
+</table>
+</details>
+{{end}}
+</body>
+</html>
+`))
+
+// HTMLReporter is a ReportWriter that writes the results of a run as a
+// single self-contained HTML file with a summary table and collapsible
+// sections for each suite. Status badges are coloured by Tc* and Suite* status
+type HTMLReporter struct {
+	name   string
+	log    *logger.GoQALog
+	parent Manager
+	output reportOutput
+}
+
+// NewHTMLReporter returns a HTMLReporter that writes the report to w
+func NewHTMLReporter(w io.Writer) *HTMLReporter {
+	return &HTMLReporter{output: reportOutput{writer: w}}
+}
+
+// NewHTMLReporterFile returns a HTMLReporter that creates fileName and
+// writes the report to it. The file is overwritten if it already exists
+func NewHTMLReporterFile(fileName string) *HTMLReporter {
+	return &HTMLReporter{output: reportOutput{fileName: fileName}}
+}
+
+func (h *HTMLReporter) Name() string {
+	return h.name
+}
+
+func (h *HTMLReporter) Init(parent Manager) {
+	h.name = "HTMLReporter"
+	h.log = parent.GetLogger()
+	h.parent = parent
+}
+
+func (h *HTMLReporter) PerformManagerStatistics(report *ManagerResult, name, msg string, complete chan int) {
+	defer func() { complete <- 1 }()
+
+	if err := h.write(NewJSONReport(report)); err != nil {
+		h.log.LogError("HTMLReporter unable to write report::error=%s", err.Error())
+	}
+}
+
+func (h *HTMLReporter) write(doc *JSONReport) error {
+	w, closer, err := h.output.open()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	return htmlReportTemplate.Execute(w, doc)
+}
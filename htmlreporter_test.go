@@ -0,0 +1,50 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestHTMLReporter(t *testing.T) {
+	var buf bytes.Buffer
+	tm := NewManager(ioutil.Discard, NewHTMLReporter(&buf), SuiteSerial, TcSerial)
+	params := Parameters{}
+	params.AddParam("markup", "<script>alert(1)</script>", "")
+	suite := NewSuite("suite1", &tm, Parameters{})
+	suite.AddTest(&checkTest{checks: []bool{true}}, "pass", params)
+	suite.AddTest(&checkTest{checks: []bool{false}}, "fail", Parameters{})
+	tm.AddSuite(suite)
+	tm.RunAll()
+
+	html := buf.String()
+	if !strings.HasPrefix(html, "<!DOCTYPE html>") || !strings.Contains(html, "</html>") {
+		t.Fatalf("not a complete HTML document:\n%s", html)
+	}
+	for _, want := range []string{"suite1", "<td>pass</td>", "<td>fail</td>", "badge failed", "&lt;script&gt;"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report doesn't contain %q", want)
+		}
+	}
+	if strings.Contains(html, "<script>alert") {
+		t.Errorf("param value isn't escaped")
+	}
+	// the log of a failed test is shown, the log of a passed test isn't
+	if n := strings.Count(html, "<summary>log output</summary>"); n != 1 {
+		t.Errorf("report has %d log outputs, want 1", n)
+	}
+}
+
+func TestHTMLStatusClasses(t *testing.T) {
+	cases := map[int]string{TcPassed: "passed", TcFailed: "failed", TcError: "error", TcSkipped: "skipped"}
+	for status, class := range cases {
+		if got := htmlTcStatusClass(status); got != class {
+			t.Errorf("htmlTcStatusClass(%s) = %q, want %q", TcStatusName(status), got, class)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// XMLInclude adds the params, hooks, suite templates, and suites of another
+// plan file, in any plan format. A relative file is in the directory of
+// the plan that includes it
+type XMLInclude struct {
+	File string `xml:"file,attr" json:"file" yaml:"file"`
+}
+
+// ExpandTestPlan replaces the includes of testPlan with the plans they name
+// and the suites that extend a suite template with the merged suite, so
+// testPlan can be given to AddTestPlan(). ParseTestPlan() expands the plans
+// it reads.
+//
+// Included plans come first. A manager param or suite template of the plan
+// replaces one with the same name from an include. A suite that extends a
+// template gets the class of the template when it has none, the tags of
+// both, and the params and test cases of the template with its own on top.
+// A test case replaces the template test case with the same name, and a
+// template can extend another template:
+//
+//	<TestManager name="Product A">
+//		<Include file="common/templates.xml"/>
+//		<SuiteTemplate name="smoke" class="DefaultSuite">
+//			<Param name="OS">Linux</Param>
+//			<TestCase name="boot" class="bootTest"/>
+//		</SuiteTemplate>
+//		<TestSuite name="smokeA" extends="smoke">
+//			<Param name="OS">Win64</Param>
+//		</TestSuite>
+//	</TestManager>
+//
+// Include cycles, unknown templates, and template cycles are returned as
+// PlanErrors
+func (tm *TestManager) ExpandTestPlan(testPlan *XMLTestPlan) error {
+	var errs PlanErrors
+	stack := []string{}
+	if testPlan.file != "" {
+		stack = append(stack, absPath(testPlan.file))
+	}
+	tm.expandIncludes(testPlan, stack, &errs)
+	testPlan.applyTemplates(&errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func absPath(fileName string) string {
+	if abs, err := filepath.Abs(fileName); err == nil {
+		return abs
+	}
+	return filepath.Clean(fileName)
+}
+
+// relativeFile returns the path of a file named in the plan, relative to
+// the directory of the plan file
+func (p *XMLTestPlan) relativeFile(fileName string) string {
+	if filepath.IsAbs(fileName) || p.file == "" {
+		return fileName
+	}
+	return filepath.Join(filepath.Dir(p.file), fileName)
+}
+
+// expandIncludes merges the plans included by testPlan, and the plans they
+// include, into it. stack has the absolute paths of the plans being
+// included, to find cycles
+func (tm *TestManager) expandIncludes(testPlan *XMLTestPlan, stack []string, errs *PlanErrors) {
+	if len(testPlan.Includes) == 0 {
+		return
+	}
+	merged := XMLTestPlan{}
+	for i, include := range testPlan.Includes {
+		location := fmt.Sprintf("/TestManager/Include[%d]", i+1)
+		addError := func(format string, args ...interface{}) {
+			*errs = append(*errs, PlanError{File: testPlan.file, Location: location, Message: fmt.Sprintf(format, args...)})
+		}
+		if include.File == "" {
+			addError("include has no file")
+			continue
+		}
+
+		fileName := testPlan.relativeFile(include.File)
+		abs := absPath(fileName)
+		if cycle := includeCycle(stack, abs); cycle != nil {
+			addError("include cycle %s", strings.Join(cycle, " -> "))
+			continue
+		}
+
+		var included XMLTestPlan
+		if err := tm.parsePlanFile(fileName, &included); err != nil {
+			addError("can't include %s: %s", fileName, err.Error())
+			continue
+		}
+		tm.expandIncludes(&included, append(stack, abs), errs)
+		included.absDataFiles()
+		included.setSuiteSources()
+		merged.merge(&included)
+	}
+	testPlan.Includes = nil
+	merged.merge(testPlan)
+	merged.Name = testPlan.Name
+	merged.file = testPlan.file
+	*testPlan = merged
+}
+
+// includeCycle returns the files of the cycle when abs is in stack
+func includeCycle(stack []string, abs string) []string {
+	for i, fileName := range stack {
+		if fileName == abs {
+			cycle := []string{}
+			for _, f := range append(append([]string{}, stack[i:]...), abs) {
+				cycle = append(cycle, filepath.Base(f))
+			}
+			return cycle
+		}
+	}
+	return nil
+}
+
+// absDataFiles makes the data files of an included plan absolute, so
+// they don't change with the directory of the plan that includes it
+func (p *XMLTestPlan) absDataFiles() {
+	for _, suites := range [][]XMLTestSuite{p.Templates, p.Suites} {
+		for i := range suites {
+			for j := range suites[i].TestCases {
+				if data := suites[i].TestCases[j].Data; data != nil {
+					data.File = absPath(p.relativeFile(data.File))
+				}
+			}
+		}
+	}
+}
+
+// setSuiteSources records the plan file and location of the suites of an
+// included plan, so PlanErrors name the file they are in. Suites from the
+// plans it includes already have theirs
+func (p *XMLTestPlan) setSuiteSources() {
+	seen := map[string]int{}
+	for i := range p.Suites {
+		if p.Suites[i].location != "" {
+			continue
+		}
+		p.Suites[i].file = p.file
+		p.Suites[i].location = nthLocation("/TestManager", "TestSuite", p.Suites[i].Name, seen)
+	}
+}
+
+// source returns the plan file and location of the suite. A suite of the
+// plan itself has no file, and its location is counted with seen
+func (s XMLTestSuite) source(seen map[string]int) (file, location string) {
+	if s.location != "" {
+		return s.file, s.location
+	}
+	return "", nthLocation("/TestManager", "TestSuite", s.Name, seen)
+}
+
+// merge adds the contents of plan to p. Params and templates of plan
+// replace the ones of p with the same name
+func (p *XMLTestPlan) merge(plan *XMLTestPlan) {
+	p.Params = mergeParams(p.Params, plan.Params)
+	p.Hooks = append(p.Hooks, plan.Hooks...)
+	for _, template := range plan.Templates {
+		replaced := false
+		for i := range p.Templates {
+			if p.Templates[i].Name == template.Name {
+				p.Templates[i] = template
+				replaced = true
+			}
+		}
+		if !replaced {
+			p.Templates = append(p.Templates, template)
+		}
+	}
+	p.Suites = append(p.Suites, plan.Suites...)
+}
+
+// mergeParams returns params with the params of over on top. A param of
+// over replaces the one in params with the same name
+func mergeParams(params, over []XMLParam) []XMLParam {
+	merged := append([]XMLParam{}, params...)
+	for _, param := range over {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == param.Name {
+				merged[i] = param
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged = append(merged, param)
+		}
+	}
+	return merged
+}
+
+// applyTemplates replaces the suites that extend a template with the
+// merged suite, and removes the templates from p
+func (p *XMLTestPlan) applyTemplates(errs *PlanErrors) {
+	templates := map[string]XMLTestSuite{}
+	for _, template := range p.Templates {
+		templates[template.Name] = template
+	}
+	seen := map[string]int{}
+	for i, suite := range p.Suites {
+		file, location := suite.source(seen)
+		if file == "" {
+			file = p.file
+		}
+		extended, err := extendSuite(suite, templates)
+		if err != nil {
+			*errs = append(*errs, PlanError{File: file, Location: location + "/@extends", Message: err.Error()})
+			continue
+		}
+		p.Suites[i] = extended
+	}
+	p.Templates = nil
+}
+
+// extendSuite returns suite merged with the templates it extends
+func extendSuite(suite XMLTestSuite, templates map[string]XMLTestSuite) (XMLTestSuite, error) {
+	chain := []string{}
+	for suite.Extends != "" {
+		name := suite.Extends
+		for i, n := range chain {
+			if n == name {
+				return suite, fmt.Errorf("suite template cycle %s", strings.Join(append(append([]string{}, chain[i:]...), name), " -> "))
+			}
+		}
+		chain = append(chain, name)
+
+		template, ok := templates[name]
+		if !ok {
+			return suite, fmt.Errorf("unknown suite template '%s'", name)
+		}
+		suite = mergeSuite(template, suite)
+	}
+	return suite, nil
+}
+
+// mergeSuite returns suite on top of template, extending the template
+// that template extends
+func mergeSuite(template, suite XMLTestSuite) XMLTestSuite {
+	merged := suite
+	merged.Extends = template.Extends
+	if merged.Class == "" {
+		merged.Class = template.Class
+	}
+	merged.Tags = strings.Join(append(splitList(template.Tags), splitList(suite.Tags)...), ",")
+	merged.Params = mergeParams(template.Params, suite.Params)
+
+	merged.TestCases = append([]XMLTestCase{}, template.TestCases...)
+	for _, test := range suite.TestCases {
+		replaced := false
+		for i := range merged.TestCases {
+			if merged.TestCases[i].Name == test.Name {
+				merged.TestCases[i] = test
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged.TestCases = append(merged.TestCases, test)
+		}
+	}
+	return merged
+}
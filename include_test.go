@@ -0,0 +1,153 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIncludeCycle(t *testing.T) {
+	dir := writeDataFiles(t, map[string]string{
+		"main.xml":   `<TestManager name="Manager"><Include file="common.xml"/></TestManager>`,
+		"common.xml": `<TestManager name="Common"><Include file="sub/../main.xml"/></TestManager>`,
+	})
+	var plan XMLTestPlan
+	err := newTestManager().ParseTestPlan(filepath.Join(dir, "main.xml"), &plan)
+	planErrors, ok := err.(PlanErrors)
+	if !ok || len(planErrors) != 1 {
+		t.Fatalf("want one PlanError, got %v", err)
+	}
+	want := PlanError{
+		File:     filepath.Join(dir, "common.xml"),
+		Location: "/TestManager/Include[1]",
+		Message:  "include cycle main.xml -> common.xml -> main.xml",
+	}
+	if planErrors[0] != want {
+		t.Errorf("error %+v, want %+v", planErrors[0], want)
+	}
+}
+
+func TestIncludedPlans(t *testing.T) {
+	dir := writeDataFiles(t, map[string]string{
+		"main.xml": `
+<TestManager name="Manager">
+  <Include file="base.yaml"/>
+  <Param name="OS">Win64</Param>
+  <TestSuite name="local" extends="smoke">
+    <Param name="OS">Linux</Param>
+    <TestCase name="extra" class="counting"/>
+  </TestSuite>
+</TestManager>`,
+		"base.yaml": `
+name: Common
+params:
+  - {name: OS, value: Mac}
+  - {name: Domain, value: goQA}
+templates:
+  - name: smoke
+    class: DefaultSuite
+    tags: smoke
+    params:
+      - {name: retries, type: int, value: 2}
+    tests:
+      - {name: boot, class: counting}
+suites:
+  - name: shared
+    tests:
+      - {name: first, class: counting}
+`,
+	})
+	var plan XMLTestPlan
+	if err := newTestManager().ParseTestPlan(filepath.Join(dir, "main.xml"), &plan); err != nil {
+		t.Fatal(err)
+	}
+	want := []XMLParam{{Name: "OS", Value: "Win64"}, {Name: "Domain", Type: "string", Value: "goQA"}}
+	if !reflect.DeepEqual(plan.Params, want) {
+		t.Errorf("params %+v, want %+v", plan.Params, want)
+	}
+	if len(plan.Suites) != 2 || plan.Suites[0].Name != "shared" || plan.Suites[1].Name != "local" {
+		t.Fatalf("suites %+v", plan.Suites)
+	}
+	local := plan.Suites[1]
+	if local.Class != "DefaultSuite" || local.Tags != "smoke" || len(local.Params) != 2 || len(local.TestCases) != 2 ||
+		local.TestCases[0].Name != "boot" || local.Extends != "" || plan.Templates != nil {
+		t.Errorf("suite extending a template %+v", local)
+	}
+}
+
+func TestIncludedSuiteErrors(t *testing.T) {
+	dir := writeDataFiles(t, map[string]string{
+		"main.xml": `
+<TestManager name="Manager">
+  <Include file="base.xml"/>
+  <TestSuite name="suite1">
+    <TestCase name="test1" class="nothing"/>
+  </TestSuite>
+  <TestSuite name="suite2" extends="missing"/>
+</TestManager>`,
+		"base.xml": `
+<TestManager name="Common">
+  <Include file="deep.xml"/>
+  <TestSuite name="suite1">
+    <TestCase name="test1" class="counting"/>
+  </TestSuite>
+  <TestSuite name="shared">
+    <TestCase name="first" class="bogus"/>
+  </TestSuite>
+</TestManager>`,
+		"deep.xml": `
+<TestManager name="Deep">
+  <TestSuite name="deep"><TestCase name="t" class="counting" dependsOn="none"/></TestSuite>
+</TestManager>`,
+	})
+	main := filepath.Join(dir, "main.xml")
+	tm := newTestManager()
+	var plan XMLTestPlan
+	err := tm.ParseTestPlan(main, &plan)
+	if err == nil || err.(PlanErrors)[0] != (PlanError{File: main, Location: "/TestManager/TestSuite[@name='suite2']/@extends", Message: "unknown suite template 'missing'"}) {
+		t.Errorf("want an unknown template error, got %v", err)
+	}
+
+	plan.Suites = plan.Suites[:len(plan.Suites)-1]
+	err = tm.ValidateTestPlan(&plan, validateRegister())
+	planErrors, ok := err.(PlanErrors)
+	if !ok {
+		t.Fatalf("want PlanErrors, got %v", err)
+	}
+	planErrors.setFile(main)
+	for _, want := range []string{
+		filepath.Join(dir, "deep.xml") + ":/TestManager/TestSuite[@name='deep']/TestCase[@name='t']/@dependsOn: depends on unknown test 'none'",
+		filepath.Join(dir, "base.xml") + ":/TestManager/TestSuite[@name='shared']/TestCase[@name='first']: unknown test class 'bogus'",
+		main + ":/TestManager/TestSuite[@name='suite1']: duplicate suite name 'suite1'",
+		main + ":/TestManager/TestSuite[@name='suite1']/TestCase[@name='test1']: unknown test class 'nothing'",
+	} {
+		found := false
+		for _, e := range planErrors {
+			if strings.HasPrefix(e.Error(), want) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no error %q in:\n%s", want, planErrors)
+		}
+	}
+}
+
+func TestSuiteTemplateCycle(t *testing.T) {
+	plan := parseXMLPlan(t, `
+<TestManager name="Manager">
+  <SuiteTemplate name="a" extends="b"><TestCase name="t" class="counting"/></SuiteTemplate>
+  <SuiteTemplate name="b" extends="a"/>
+  <TestSuite name="suite1" extends="a"/>
+</TestManager>`)
+	err := newTestManager().ExpandTestPlan(plan)
+	want := "/TestManager/TestSuite[@name='suite1']/@extends: suite template cycle a -> b -> a"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,496 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// resolveError is a problem with params[index] found by resolveParams()
+type resolveError struct {
+	index int
+	err   error
+}
+
+// cycleError is returned for params that reference each other
+type cycleError struct {
+	names []string
+}
+
+func (e cycleError) Error() string {
+	return fmt.Sprintf("reference cycle %s", strings.Join(e.names, " -> "))
+}
+
+// resolveParams interpolates the ${...} references of params, converts
+// them to their type, and returns them merged with the params of parent,
+// which is nil for the manager. References are looked up in the overrides
+// for suiteName and testNames, params, parent, and last the environment
+func resolveParams(params []XMLParam, source ParamSource, parent *Parameters,
+	overrides []paramOverride, suiteName string, testNames ...string) (*Parameters, []resolveError) {
+	r := &paramResolver{
+		raw:       map[string]XMLParam{},
+		parent:    parent,
+		overrides: overrides,
+		suite:     suiteName,
+		tests:     testNames,
+		values:    map[string]interface{}{},
+	}
+	for _, param := range params {
+		r.raw[param.Name] = param
+	}
+
+	resolved := new(Parameters)
+	resolved.Init()
+	errs := []resolveError{}
+	for i, param := range params {
+		value, err := r.resolve(param.Name)
+		if err != nil {
+			errs = append(errs, resolveError{i, err})
+			value = param.Value
+		}
+		resolved.addFrom(param.Name, value, param.Comment, source)
+	}
+	if parent != nil {
+		resolved.inherit(parent)
+	}
+	return resolved, errs
+}
+
+// paramResolver resolves the params of one test plan element
+type paramResolver struct {
+	raw       map[string]XMLParam
+	parent    *Parameters
+	overrides []paramOverride
+	suite     string
+	tests     []string
+	values    map[string]interface{} // resolved params of raw
+	resolving []string               // params being resolved, to find cycles
+}
+
+// resolve returns the value of raw param name with its references
+// interpolated and converted to its type
+func (r *paramResolver) resolve(name string) (interface{}, error) {
+	if value, ok := r.values[name]; ok {
+		return value, nil
+	}
+	for i, n := range r.resolving {
+		if n == name {
+			return nil, cycleError{append(append([]string{}, r.resolving[i:]...), name)}
+		}
+	}
+	r.resolving = append(r.resolving, name)
+	defer func() { r.resolving = r.resolving[:len(r.resolving)-1] }()
+
+	param := r.raw[name]
+	text, err := interpolate(param.Value, r.lookup)
+	if err != nil {
+		return nil, err
+	}
+	value, err := parseParamValue(text, param.Type)
+	if err == nil && param.GoType != "" {
+		value, err = convertGoType(value, param.GoType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.values[name] = value
+	return value, nil
+}
+
+// lookup returns the value of a ${name} reference. A param that references
+// itself, like "${Path}/bin", gets the value of the parent element
+func (r *paramResolver) lookup(name string) (interface{}, error) {
+	for i := len(r.overrides) - 1; i >= 0; i-- {
+		if o := r.overrides[i]; o.name == name && o.applies(r.suite, r.tests...) {
+			return o.value, nil
+		}
+	}
+	self := len(r.resolving) > 0 && r.resolving[len(r.resolving)-1] == name
+	if _, ok := r.raw[name]; ok && !self {
+		value, err := r.resolve(name)
+		if _, isCycle := err.(cycleError); err != nil && !isCycle {
+			err = fmt.Errorf("reference '${%s}': %s", name, err.Error())
+		}
+		return value, err
+	}
+	if r.parent != nil {
+		if value, ok := r.parent.GetParamValue(name); ok {
+			return value, nil
+		}
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value, nil
+	}
+	return nil, fmt.Errorf("unresolved reference '${%s}'", name)
+}
+
+// interpolate replaces each ${expression} in text with its value. "$${"
+// is a literal "${". Expressions have param names, numbers, "strings",
+// + - * / % and parentheses, and the functions now([layout]),
+// env(name[, default]), and uuid()
+func interpolate(text string, lookup func(name string) (interface{}, error)) (string, error) {
+	if !strings.Contains(text, "${") {
+		return text, nil
+	}
+	var out strings.Builder
+	for {
+		start := strings.Index(text, "${")
+		if start < 0 {
+			out.WriteString(text)
+			return out.String(), nil
+		}
+		if start > 0 && text[start-1] == '$' {
+			out.WriteString(text[:start-1])
+			out.WriteString("${")
+			text = text[start+2:]
+			continue
+		}
+		out.WriteString(text[:start])
+		end := exprEnd(text[start+2:])
+		if end < 0 {
+			return "", fmt.Errorf("missing '}' in '%s'", text[start:])
+		}
+		expr := text[start+2 : start+2+end]
+		value, err := evalExpr(expr, lookup)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(formatParamValue(value))
+		text = text[start+2+end+1:]
+	}
+}
+
+// exprEnd returns the index of the '}' that ends expr, skipping quoted strings
+func exprEnd(expr string) int {
+	var quote rune
+	for i, c := range expr {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '}':
+			return i
+		}
+	}
+	return -1
+}
+
+// formatParamValue formats value so it converts back with its param type
+func formatParamValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Slice, reflect.Map:
+		if buf, err := json.Marshal(value); err == nil {
+			return string(buf)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// ---------------------------  Expressions -------------------
+
+// exprParser evaluates an expression of a ${...} reference while parsing it
+type exprParser struct {
+	text   string
+	pos    int
+	lookup func(name string) (interface{}, error)
+	refErr bool // the error is from lookup, which names the reference
+}
+
+func evalExpr(expr string, lookup func(name string) (interface{}, error)) (interface{}, error) {
+	p := &exprParser{text: expr, lookup: lookup}
+	value, err := p.parseSum()
+	if err != nil && p.refErr {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("in '${%s}': %s", expr, err.Error())
+	}
+	if p.skipSpace(); p.pos < len(p.text) {
+		return nil, fmt.Errorf("in '${%s}': unexpected '%s'", expr, p.text[p.pos:])
+	}
+	return value, nil
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.text) && unicode.IsSpace(rune(p.text[p.pos])) {
+		p.pos++
+	}
+}
+
+// next returns the next operator or punctuation character, or 0 at the end
+func (p *exprParser) next() byte {
+	p.skipSpace()
+	if p.pos < len(p.text) {
+		return p.text[p.pos]
+	}
+	return 0
+}
+
+func (p *exprParser) parseSum() (interface{}, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.next(); op == '+' || op == '-'; op = p.next() {
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = arith(op, left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseProduct() (interface{}, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.next(); op == '*' || op == '/' || op == '%'; op = p.next() {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = arith(op, left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (interface{}, error) {
+	if p.next() == '-' {
+		p.pos++
+		value, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return arith('-', int64(0), value)
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (interface{}, error) {
+	c := p.next()
+	switch {
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of expression")
+	case c == '(':
+		p.pos++
+		value, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ')' {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		return value, nil
+	case c == '"' || c == '\'':
+		end := strings.IndexByte(p.text[p.pos+1:], c)
+		if end < 0 {
+			return nil, fmt.Errorf("missing closing %c", c)
+		}
+		s := p.text[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return s, nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.text) && (p.text[p.pos] >= '0' && p.text[p.pos] <= '9' || p.text[p.pos] == '.') {
+			p.pos++
+		}
+		return toNumber(p.text[start:p.pos])
+	case c == '_' || unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.text) && isNameChar(p.text[p.pos]) {
+			p.pos++
+		}
+		name := p.text[start:p.pos]
+		if p.next() == '(' {
+			p.pos++
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			return callFunc(name, args)
+		}
+		value, err := p.lookup(name)
+		p.refErr = err != nil
+		return value, err
+	}
+	return nil, fmt.Errorf("unexpected '%s'", p.text[p.pos:])
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c == '.' || c >= '0' && c <= '9' || unicode.IsLetter(rune(c))
+}
+
+// parseArgs parses the arguments of a function after its '('
+func (p *exprParser) parseArgs() ([]interface{}, error) {
+	args := []interface{}{}
+	if p.next() == ')' {
+		p.pos++
+		return args, nil
+	}
+	for {
+		arg, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		switch p.next() {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return args, nil
+		default:
+			return nil, fmt.Errorf("missing ')' after function arguments")
+		}
+	}
+}
+
+// callFunc calls the built-in function name
+func callFunc(name string, args []interface{}) (interface{}, error) {
+	switch name {
+	case "now":
+		if len(args) > 1 {
+			return nil, fmt.Errorf("now() takes an optional layout")
+		}
+		if len(args) == 1 {
+			return time.Now().Format(formatParamValue(args[0])), nil
+		}
+		return time.Now().Format(time.RFC3339), nil
+	case "env":
+		if len(args) < 1 || len(args) > 2 {
+			return nil, fmt.Errorf("env() takes a variable name and an optional default")
+		}
+		if value, ok := os.LookupEnv(formatParamValue(args[0])); ok {
+			return value, nil
+		}
+		if len(args) == 2 {
+			return args[1], nil
+		}
+		return nil, fmt.Errorf("environment variable '%s' is not set", formatParamValue(args[0]))
+	case "uuid":
+		if len(args) != 0 {
+			return nil, fmt.Errorf("uuid() takes no arguments")
+		}
+		return newUUID()
+	}
+	return nil, fmt.Errorf("unknown function '%s'", name)
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// toNumber converts value to int64 or float64. Strings are parsed
+func toNumber(value interface{}) (interface{}, error) {
+	if s, ok := value.(string); ok {
+		s = strings.TrimSpace(s)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, nil
+		}
+		return nil, fmt.Errorf("'%s' is not a number", s)
+	}
+	if i, err := toInt64(value); err == nil {
+		return i, nil
+	}
+	if f, err := toFloat64(value); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("%v (%T) is not a number", value, value)
+}
+
+// arith applies op to left and right. + adds numbers and joins anything
+// else as strings. Integers stay integers unless a division has a fraction
+func arith(op byte, left, right interface{}) (interface{}, error) {
+	l, lErr := toNumber(left)
+	r, rErr := toNumber(right)
+	if lErr != nil || rErr != nil {
+		if op == '+' {
+			return formatParamValue(left) + formatParamValue(right), nil
+		}
+		if lErr != nil {
+			return nil, fmt.Errorf("operator %c: %s", op, lErr.Error())
+		}
+		return nil, fmt.Errorf("operator %c: %s", op, rErr.Error())
+	}
+
+	li, lInt := l.(int64)
+	ri, rInt := r.(int64)
+	if lInt && rInt {
+		switch op {
+		case '+':
+			return li + ri, nil
+		case '-':
+			return li - ri, nil
+		case '*':
+			return li * ri, nil
+		case '/', '%':
+			if ri == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			if op == '%' {
+				return li % ri, nil
+			}
+			if li%ri == 0 {
+				return li / ri, nil
+			}
+		}
+	}
+
+	lf, _ := toFloat64(l)
+	rf, _ := toFloat64(r)
+	switch op {
+	case '+':
+		return lf + rf, nil
+	case '-':
+		return lf - rf, nil
+	case '*':
+		return lf * rf, nil
+	}
+	if rf == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	if op == '/' {
+		return lf / rf, nil
+	}
+	return math.Mod(lf, rf), nil
+}
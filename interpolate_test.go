@@ -0,0 +1,115 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	values := map[string]interface{}{
+		"host":  "example.com",
+		"port":  int64(8080),
+		"ratio": 1.5,
+		"hosts": []string{"a", "b"},
+	}
+	lookup := func(name string) (interface{}, error) {
+		if value, ok := values[name]; ok {
+			return value, nil
+		}
+		return nil, fmt.Errorf("unresolved reference '${%s}'", name)
+	}
+	t.Setenv("GOQA_TEST_REGION", "eu")
+
+	for text, want := range map[string]string{
+		"plain":                           "plain",
+		"http://${host}:${port}/":         "http://example.com:8080/",
+		"${port + 1}":                     "8081",
+		"${(port - 80) / 1000 * 2}":       "16",
+		"${7 / 2}":                        "3.5",
+		"${7 % 4}":                        "3",
+		"${-ratio * 2}":                   "-3",
+		"${host + ':' + port}":            "example.com:8080",
+		"${'a}b'}":                        "a}b",
+		"$${host}":                        "${host}",
+		"${hosts}":                        `["a","b"]`,
+		`${env("GOQA_TEST_REGION")}`:      "eu",
+		`${env("GOQA_TEST_MISSING", 10)}`: "10",
+	} {
+		got, err := interpolate(text, lookup)
+		if err != nil {
+			t.Errorf("%s: %s", text, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s is %q, want %q", text, got, want)
+		}
+	}
+
+	uuid, err := interpolate("${uuid()}", lookup)
+	if err != nil || !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(uuid) {
+		t.Errorf("uuid() is %q, %v", uuid, err)
+	}
+	if year, err := interpolate(`${now("2006")}`, lookup); err != nil || len(year) != 4 {
+		t.Errorf("now() is %q, %v", year, err)
+	}
+
+	for text, want := range map[string]string{
+		"${host":                   "missing '}'",
+		"${missing}":               "unresolved reference '${missing}'",
+		"${port / 0}":              "division by zero",
+		"${host * 2}":              "operator *: 'example.com' is not a number",
+		"${(port}":                 "missing ')'",
+		"${port port}":             "unexpected 'port'",
+		"${bogus()}":               "unknown function 'bogus'",
+		`${env("GOQA_TEST_NONE")}`: "environment variable 'GOQA_TEST_NONE' is not set",
+	} {
+		if _, err := interpolate(text, lookup); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: want error %q, got %v", text, want, err)
+		}
+	}
+}
+
+func TestResolveParams(t *testing.T) {
+	parent := Parameters{}
+	parent.AddParam("Path", "/usr", "")
+	parent.AddParam("count", int64(2), "")
+	params := []XMLParam{
+		{Name: "total", Type: "int", Value: "${count * size}"},
+		{Name: "size", Type: "int", Value: "${base}0"},
+		{Name: "base", Type: "int", Value: "4"},
+		{Name: "Path", Value: "${Path}/bin"},
+		{Name: "region", Value: "${REGION}"},
+	}
+	overrides := []paramOverride{{suite: "suite1", name: "REGION", value: "us"}}
+	resolved, errs := resolveParams(params, ParamSource{Kind: ParamFromTest}, &parent, overrides, "suite1", "test1")
+	if len(errs) > 0 {
+		t.Fatalf("errors %v", errs)
+	}
+	for name, want := range map[string]interface{}{
+		"total":  int64(80),
+		"size":   int64(40),
+		"Path":   "/usr/bin",
+		"region": "us",
+		"count":  int64(2),
+	} {
+		if value, _ := resolved.GetParamValue(name); value != want {
+			t.Errorf("%s is %#v, want %#v", name, value, want)
+		}
+	}
+
+	cycle := []XMLParam{
+		{Name: "a", Value: "${b}"},
+		{Name: "b", Value: "${a}"},
+		{Name: "c", Type: "int", Value: "x${a}"},
+	}
+	_, errs = resolveParams(cycle, ParamSource{Kind: ParamFromTest}, nil, nil, "", "")
+	if len(errs) != 3 || errs[0].err.Error() != "reference cycle a -> b -> a" || errs[2].index != 2 {
+		t.Errorf("cycle errors %v", errs)
+	}
+}
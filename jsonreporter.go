@@ -0,0 +1,275 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/go-QA/logger"
+)
+
+// JSONReportVersion is the schema version written to JSONReport.SchemaVersion.
+// It is increased when a field is removed or changes meaning. New fields
+// can be added without changing the version.
+const JSONReportVersion = 1
+
+// ---------------------------  Define JSON for result reports -------------------
+
+// JSONParam is a parameter a test ran with. Type is the Go type of the
+// value, like "int", "int64", "float64", or "string", because numbers
+// decode from JSON as float64. Overrides are the params with the same name
+// it replaced, nearest first, see Parameter.Overrides()
+type JSONParam struct {
+	Name      string      `json:"name"`
+	Type      string      `json:"type"`
+	Value     interface{} `json:"value"`
+	Comment   string      `json:"comment,omitempty"`
+	Source    ParamSource `json:"source"`
+	Overrides []JSONParam `json:"overrides,omitempty"`
+}
+
+// newJSONParam creates the JSONParam for param
+func newJSONParam(param Parameter) JSONParam {
+	jParam := JSONParam{
+		Name:    param.Name(),
+		Type:    fmt.Sprintf("%T", param.Value()),
+		Value:   param.Value(),
+		Comment: param.Comment(),
+		Source:  param.Source(),
+	}
+	for _, o := range param.overrides {
+		jParam.Overrides = append(jParam.Overrides, newJSONParam(o))
+	}
+	return jParam
+}
+
+// JSONTestResult is the result of one test case
+type JSONTestResult struct {
+	Name          string      `json:"name"`
+	Start         time.Time   `json:"start"`
+	End           time.Time   `json:"end"`
+	Duration      float64     `json:"durationSec"`
+	Status        int         `json:"status"`
+	StatusName    string      `json:"statusName"`
+	StatusMessage string      `json:"statusMessage"`
+	Output        string      `json:"output,omitempty"`
+	Params        []JSONParam `json:"params"`
+
+	// Matrix has the values of the params set by the matrix of a test
+	// generated from a matrix test, see XMLMatrix
+	Matrix map[string]interface{} `json:"matrix,omitempty"`
+
+	// Data is the data file row a test was generated from, see XMLData
+	Data *JSONDataRow `json:"data,omitempty"`
+
+	// Attempts is the number of times the test ran. PreviousAttempts has the
+	// results of the attempts before the last one when the test was retried
+	Attempts         int              `json:"attempts"`
+	PreviousAttempts []JSONTestResult `json:"previousAttempts,omitempty"`
+}
+
+// JSONDataRow is the data file and row of a test generated from a data file
+type JSONDataRow struct {
+	File string `json:"file"`
+	Row  int    `json:"row"`
+}
+
+// JSONSuiteResult is the result of one suite with the results of its tests
+type JSONSuiteResult struct {
+	Name          string           `json:"name"`
+	Start         time.Time        `json:"start"`
+	End           time.Time        `json:"end"`
+	Duration      float64          `json:"durationSec"`
+	Status        int              `json:"status"`
+	StatusName    string           `json:"statusName"`
+	StatusMessage string           `json:"statusMessage"`
+	Tests         []JSONTestResult `json:"tests"`
+}
+
+// JSONReport is the document written by JSONReporter. Schema:
+//
+//	{
+//	  "schemaVersion": 1,
+//	  "name": "Test Manager",
+//	  "start": "2016-11-21T23:26:07.027856-05:00",   (RFC 3339)
+//	  "end": "...",
+//	  "durationSec": 1.5,
+//	  "status": 1, "statusName": "Passed", "statusMessage": "",
+//	  "statistics": { "NumberOfTestSuites": 2, ... },  (ReporterStatistics)
+//	  "suites": [ {
+//	    "name", "start", "end", "durationSec", "status", "statusName", "statusMessage",
+//	    "tests": [ {
+//	      "name", "start", "end", "durationSec", "status", "statusName", "statusMessage",
+//	      "output": "PASS::...",
+//	      "params": [ {"name": "val", "type": "int", "value": 10, "comment": "...",
+//	                   "source": {"kind": "test", "location": "/TestManager/TestSuite[@name='suite1']/TestCase[@name='test1']"},
+//	                   "overrides": [ {"name": "val", ..., "source": {"kind": "suite", ...}} ]} ],
+//	      "matrix": {"voltage": 3.3, "temp": 25},   (only for tests generated from a matrix)
+//	      "data": {"file": "cases.csv", "row": 3},  (only for tests generated from a data file)
+//	      "attempts": 2,
+//	      "previousAttempts": [ { "name", "start", ... } ]   (only when retried)
+//	    } ]
+//	  } ]
+//	}
+//
+// Manager status codes are Manager*, suite codes Suite* and test codes Tc*
+type JSONReport struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	Name          string             `json:"name"`
+	Start         time.Time          `json:"start"`
+	End           time.Time          `json:"end"`
+	Duration      float64            `json:"durationSec"`
+	Status        int                `json:"status"`
+	StatusName    string             `json:"statusName"`
+	StatusMessage string             `json:"statusMessage"`
+	Statistics    ReporterStatistics `json:"statistics"`
+	Suites        []JSONSuiteResult  `json:"suites"`
+}
+
+// DecodeJSONReport reads a report written by JSONReporter from r.
+// error is returned if the report has a newer schema version than JSONReportVersion
+func DecodeJSONReport(r io.Reader) (*JSONReport, error) {
+	report := new(JSONReport)
+	if err := json.NewDecoder(r).Decode(report); err != nil {
+		return nil, err
+	}
+	if report.SchemaVersion > JSONReportVersion {
+		return nil, fmt.Errorf("JSON report schema version %d is newer than supported version %d",
+			report.SchemaVersion, JSONReportVersion)
+	}
+	return report, nil
+}
+
+// LoadJSONReport reads a report written by JSONReporter from fileName
+func LoadJSONReport(fileName string) (*JSONReport, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeJSONReport(f)
+}
+
+// --------------------------------------------------------------
+
+// JSONReporter is a ReportWriter that writes the results of a run as a
+// JSONReport document to an io.Writer or file
+type JSONReporter struct {
+	name   string
+	log    *logger.GoQALog
+	parent Manager
+	output reportOutput
+}
+
+// NewJSONReporter returns a JSONReporter that writes the report to w
+func NewJSONReporter(w io.Writer) *JSONReporter {
+	return &JSONReporter{output: reportOutput{writer: w}}
+}
+
+// NewJSONReporterFile returns a JSONReporter that creates fileName and
+// writes the report to it. The file is overwritten if it already exists
+func NewJSONReporterFile(fileName string) *JSONReporter {
+	return &JSONReporter{output: reportOutput{fileName: fileName}}
+}
+
+func (j *JSONReporter) Name() string {
+	return j.name
+}
+
+func (j *JSONReporter) Init(parent Manager) {
+	j.name = "JSONReporter"
+	j.log = parent.GetLogger()
+	j.parent = parent
+}
+
+func (j *JSONReporter) PerformManagerStatistics(report *ManagerResult, name, msg string, complete chan int) {
+	defer func() { complete <- 1 }()
+
+	if err := j.write(NewJSONReport(report)); err != nil {
+		j.log.LogError("JSONReporter unable to write report::error=%s", err.Error())
+	}
+}
+
+func (j *JSONReporter) write(doc *JSONReport) error {
+	w, closer, err := j.output.open()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(doc)
+}
+
+// NewJSONReport creates the JSONReport document from the results in report
+func NewJSONReport(report *ManagerResult) *JSONReport {
+	doc := &JSONReport{
+		SchemaVersion: JSONReportVersion,
+		Name:          report.Name(),
+		Start:         report.start,
+		End:           report.end,
+		Duration:      report.Runtime(),
+		Status:        report.Status,
+		StatusName:    ManagerStatusName(report.Status),
+		StatusMessage: report.StatusMessage,
+		Statistics:    report.reportStats,
+		Suites:        make([]JSONSuiteResult, 0, len(report.finishedSuites)),
+	}
+
+	for _, suite := range report.GetSuites() {
+		jSuite := JSONSuiteResult{
+			Name:          suite.Name(),
+			Start:         suite.start,
+			End:           suite.end,
+			Duration:      suite.Runtime(),
+			Status:        suite.Status,
+			StatusName:    SuiteStatusName(suite.Status),
+			StatusMessage: suite.StatusMessage,
+			Tests:         make([]JSONTestResult, 0, len(suite.tests)),
+		}
+
+		for _, test := range suite.GetTests() {
+			jSuite.Tests = append(jSuite.Tests, newJSONTestResult(test))
+		}
+		doc.Suites = append(doc.Suites, jSuite)
+	}
+	return doc
+}
+
+// newJSONTestResult creates the JSONTestResult for test and its earlier attempts
+func newJSONTestResult(test testResult) JSONTestResult {
+	jTest := JSONTestResult{
+		Name:          test.Name(),
+		Start:         test.start,
+		End:           test.end,
+		Duration:      test.Runtime(),
+		Status:        test.Status,
+		StatusName:    TcStatusName(test.Status),
+		StatusMessage: test.StatusMessage,
+		Output:        test.Output(),
+		Params:        make([]JSONParam, 0, test.params.Count()),
+		Attempts:      test.Attempts(),
+	}
+	for _, paramName := range test.params.Names() {
+		param, _ := test.params.GetParam(paramName)
+		jTest.Params = append(jTest.Params, newJSONParam(param))
+	}
+	if matrix := matrixValues(&test.params); len(matrix) > 0 {
+		jTest.Matrix = matrix
+	}
+	if file, row, ok := dataRowOf(&test.params); ok {
+		jTest.Data = &JSONDataRow{File: file, Row: row}
+	}
+	for _, attempt := range test.PreviousAttempts() {
+		jTest.PreviousAttempts = append(jTest.PreviousAttempts, newJSONTestResult(attempt))
+	}
+	return jTest
+}
@@ -0,0 +1,95 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJSONReportRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	tm := NewManager(ioutil.Discard, NewJSONReporter(&buf), SuiteSerial, TcSerial)
+	params := Parameters{}
+	params.AddParam("val", 10, "a value")
+	suite := NewSuite("suite1", &tm, Parameters{})
+	suite.AddTest(&checkTest{checks: []bool{true}}, "pass", params)
+	suite.AddTest(&checkTest{checks: []bool{false}}, "fail", Parameters{})
+	tm.AddSuite(suite)
+	tm.RunAll()
+
+	report, err := DecodeJSONReport(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("DecodeJSONReport: %v", err)
+	}
+	if report.SchemaVersion != JSONReportVersion || report.Name != "Test Manager" {
+		t.Errorf("schema version %d and name %q", report.SchemaVersion, report.Name)
+	}
+	if len(report.Suites) != 1 || len(report.Suites[0].Tests) != 2 {
+		t.Fatalf("want 1 suite with 2 tests, got %+v", report.Suites)
+	}
+	tests := report.Suites[0].Tests
+	if tests[0].StatusName != "Passed" || tests[1].StatusName != "Failed" || tests[1].Status != TcFailed {
+		t.Errorf("statuses %q and %q (%d)", tests[0].StatusName, tests[1].StatusName, tests[1].Status)
+	}
+	if report.Statistics.TotalNumberOfTestCases != 2 || report.Statistics.TotalNumberOfTestCasesFailed != 1 {
+		t.Errorf("statistics %+v", report.Statistics)
+	}
+	found := false
+	for _, param := range tests[0].Params {
+		if param.Name == "val" {
+			found = true
+			if param.Type != "int" || param.Value != 10.0 || param.Comment != "a value" {
+				t.Errorf("param val is %+v", param)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("param val is missing from %+v", tests[0].Params)
+	}
+
+	// the decoded report is written the same way again
+	var again bytes.Buffer
+	enc := json.NewEncoder(&again)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(report); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != buf.String() {
+		t.Errorf("report changed after decoding:\n%s\nwant:\n%s", again.String(), buf.String())
+	}
+}
+
+func TestDecodeJSONReportNewerVersion(t *testing.T) {
+	_, err := DecodeJSONReport(strings.NewReader(`{"schemaVersion": 999}`))
+	if err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("want an error for a newer schema version, got %v", err)
+	}
+	if _, err := DecodeJSONReport(strings.NewReader(`{`)); err == nil {
+		t.Errorf("no error for invalid JSON")
+	}
+}
+
+func TestLoadJSONReport(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "report.json")
+	tm := NewManager(ioutil.Discard, NewJSONReporterFile(fileName), SuiteSerial, TcSerial)
+	suite := NewSuite("suite1", &tm, Parameters{})
+	suite.AddTest(&checkTest{}, "pass", Parameters{})
+	tm.AddSuite(suite)
+	tm.RunAll()
+
+	report, err := LoadJSONReport(fileName)
+	if err != nil {
+		t.Fatalf("LoadJSONReport: %v", err)
+	}
+	if len(report.Suites) != 1 || report.Suites[0].Tests[0].Name != "pass" {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
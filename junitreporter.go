@@ -0,0 +1,227 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-QA/logger"
+)
+
+// ---------------------------  Define XML for JUnit reports -------------------
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",cdata"`
+}
+
+type junitOutput struct {
+	Data string `xml:",cdata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name       string           `xml:"name,attr"`
+	ClassName  string           `xml:"classname,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Failure    *junitFailure    `xml:"failure,omitempty"`
+	Error      *junitFailure    `xml:"error,omitempty"`
+	Skipped    *junitSkipped    `xml:"skipped,omitempty"`
+	SystemOut  *junitOutput     `xml:"system-out,omitempty"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// --------------------------------------------------------------
+
+// JUnitReporter is a ReportWriter that writes the results of a run as
+// JUnit XML so they can be consumed by CI servers.
+//
+// Results are written to the io.Writer or file given to NewJUnitReporter()
+// or NewJUnitReporterFile() when PerformManagerStatistics() is called
+type JUnitReporter struct {
+	name   string
+	log    *logger.GoQALog
+	parent Manager
+	output reportOutput
+}
+
+// NewJUnitReporter returns a JUnitReporter that writes the XML report to w
+func NewJUnitReporter(w io.Writer) *JUnitReporter {
+	return &JUnitReporter{output: reportOutput{writer: w}}
+}
+
+// NewJUnitReporterFile returns a JUnitReporter that creates fileName and
+// writes the XML report to it. The file is overwritten if it already exists
+func NewJUnitReporterFile(fileName string) *JUnitReporter {
+	return &JUnitReporter{output: reportOutput{fileName: fileName}}
+}
+
+func (j *JUnitReporter) Name() string {
+	return j.name
+}
+
+func (j *JUnitReporter) Init(parent Manager) {
+	j.name = "JUnitReporter"
+	j.log = parent.GetLogger()
+	j.parent = parent
+}
+
+func (j *JUnitReporter) PerformManagerStatistics(report *ManagerResult, name, msg string, complete chan int) {
+	defer func() { complete <- 1 }()
+
+	if err := j.write(j.buildReport(report, name)); err != nil {
+		j.log.LogError("JUnitReporter unable to write report::error=%s", err.Error())
+	}
+}
+
+func (j *JUnitReporter) write(doc *junitTestSuites) error {
+	w, closer, err := j.output.open()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func (j *JUnitReporter) buildReport(report *ManagerResult, name string) *junitTestSuites {
+	doc := &junitTestSuites{
+		Name: name,
+		Time: junitSeconds(report.Runtime()),
+	}
+
+	for _, suite := range report.GetSuites() {
+		jSuite := junitTestSuite{
+			Name:      suite.Name(),
+			Time:      junitSeconds(suite.Runtime()),
+			Timestamp: suite.start.Format(time.RFC3339),
+			TestCases: make([]junitTestCase, 0, len(suite.tests)),
+		}
+
+		for _, test := range suite.GetTests() {
+			jTest := junitTestCase{
+				Name:      test.Name(),
+				ClassName: suite.Name(),
+				Time:      junitSeconds(test.Runtime()),
+			}
+			if test.Output() != "" {
+				jTest.SystemOut = &junitOutput{test.Output()}
+			}
+			if properties := j.properties(test); len(properties) > 0 {
+				jTest.Properties = &junitProperties{properties}
+			}
+
+			switch test.Status {
+			case TcPassed:
+			case TcFlaky:
+				// a flaky test passed, so only the retries are noted in system-out
+				jTest.SystemOut = &junitOutput{fmt.Sprintf("%s\n\n%s", test.StatusMessage, test.Output())}
+			case TcSkipped:
+				jTest.Skipped = &junitSkipped{Message: test.StatusMessage}
+				jSuite.Skipped++
+			case TcFailed, TcCriticalError, TcSetupFailed, TcTeardownFailed:
+				jTest.Failure = j.failure(test)
+				jSuite.Failures++
+			default:
+				jTest.Error = j.failure(test)
+				jSuite.Errors++
+			}
+			jSuite.Tests++
+			jSuite.TestCases = append(jSuite.TestCases, jTest)
+		}
+
+		doc.Tests += jSuite.Tests
+		doc.Failures += jSuite.Failures
+		doc.Errors += jSuite.Errors
+		doc.Skipped += jSuite.Skipped
+		doc.Suites = append(doc.Suites, jSuite)
+	}
+	return doc
+}
+
+// properties returns the values of a test generated from a matrix, sorted
+// by name, or the data file and row of a test generated from a data file,
+// and the number of attempts of a test that was retried
+func (j *JUnitReporter) properties(test testResult) []junitProperty {
+	properties := []junitProperty{}
+	matrix := matrixValues(&test.params)
+	for _, paramName := range test.params.Names() {
+		if value, ok := matrix[paramName]; ok {
+			properties = append(properties, junitProperty{paramName, formatParamValue(value)})
+		}
+	}
+	if file, row, ok := dataRowOf(&test.params); ok {
+		properties = append(properties, junitProperty{"dataFile", file}, junitProperty{"dataRow", fmt.Sprint(row)})
+	}
+	if test.Attempts() > 1 {
+		properties = append(properties, junitProperty{"attempts", fmt.Sprint(test.Attempts())})
+	}
+	return properties
+}
+
+// failure creates the failure or error element for test with the
+// status message and captured log output as body
+func (j *JUnitReporter) failure(test testResult) *junitFailure {
+	body := test.StatusMessage
+	if test.Output() != "" {
+		body = fmt.Sprintf("%s\n\n%s", body, test.Output())
+	}
+	return &junitFailure{
+		Message: test.StatusMessage,
+		Type:    TcStatusName(test.Status),
+		Body:    body,
+	}
+}
+
+func junitSeconds(sec float64) string {
+	return fmt.Sprintf("%.3f", sec)
+}
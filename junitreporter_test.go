@@ -0,0 +1,107 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// checkTest verifies checks in Run() and returns the status of
+// ReturnFromRun(), or panics when broken
+type checkTest struct {
+	TestCase
+	checks []bool
+	broken bool
+}
+
+func (t *checkTest) Run() (int, error) {
+	if t.broken {
+		panic("run broke")
+	}
+	for i, ok := range t.checks {
+		t.Verify(ok, fmt.Sprintf("check %d", i), "check %d failed", i)
+	}
+	return t.ReturnFromRun()
+}
+
+func TestJUnitReporter(t *testing.T) {
+	var buf bytes.Buffer
+	tm := NewManager(ioutil.Discard, NewJUnitReporter(&buf), SuiteSerial, TcSerial)
+	suite := NewSuite("suite1", &tm, Parameters{})
+	suite.AddTest(&checkTest{checks: []bool{true}}, "pass", Parameters{})
+	suite.AddTest(&checkTest{checks: []bool{true, false}}, "fail", Parameters{})
+	suite.AddTest(&checkTest{broken: true}, "broken", Parameters{})
+	tm.AddSuite(suite)
+	tm.RunAll()
+
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Errorf("report doesn't start with the XML header:\n%s", buf.String())
+	}
+	var doc junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("can't read report: %v\n%s", err, buf.String())
+	}
+	if doc.Tests != 3 || doc.Failures != 1 || doc.Errors != 1 || doc.Skipped != 0 {
+		t.Errorf("totals tests=%d failures=%d errors=%d skipped=%d, want 3, 1, 1, 0",
+			doc.Tests, doc.Failures, doc.Errors, doc.Skipped)
+	}
+	if len(doc.Suites) != 1 || len(doc.Suites[0].TestCases) != 3 {
+		t.Fatalf("want 1 suite with 3 test cases, got %+v", doc.Suites)
+	}
+
+	cases := map[string]junitTestCase{}
+	for _, tc := range doc.Suites[0].TestCases {
+		cases[tc.Name] = tc
+		if tc.ClassName != "suite1" {
+			t.Errorf("test %s has classname %q, want suite1", tc.Name, tc.ClassName)
+		}
+	}
+	if tc := cases["pass"]; tc.Failure != nil || tc.Error != nil || tc.SystemOut == nil || !strings.Contains(tc.SystemOut.Data, "PASS::check 0") {
+		t.Errorf("passed test has failure %v, error %v, output %+v", tc.Failure, tc.Error, tc.SystemOut)
+	}
+	if tc := cases["fail"]; tc.Failure == nil || tc.Failure.Type != "Failed" {
+		t.Errorf("failed test has failure %+v, want type Failed", tc.Failure)
+	}
+	if tc := cases["broken"]; tc.Error == nil || !strings.Contains(tc.Error.Message, "run broke") {
+		t.Errorf("test that panicked has error %+v, want the panic message", tc.Error)
+	}
+}
+
+func TestJUnitReporterSkipped(t *testing.T) {
+	var buf bytes.Buffer
+	tm := NewManager(ioutil.Discard, NewJUnitReporter(&buf), SuiteSerial, TcSerial)
+	suite := NewSuite("suite1", &tm, Parameters{})
+	suite.AddTest(&checkTest{checks: []bool{false}}, "first", Parameters{})
+	suite.AddTest(&checkTest{checks: []bool{true}}, "second", Parameters{})
+	suite.AddDependency("second", "first")
+	tm.AddSuite(suite)
+	tm.RunAll()
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("can't read report: %v", err)
+	}
+	if doc.Skipped != 1 || doc.Suites[0].Skipped != 1 {
+		t.Errorf("skipped %d in report and %d in suite, want 1", doc.Skipped, doc.Suites[0].Skipped)
+	}
+	for _, tc := range doc.Suites[0].TestCases {
+		if tc.Name == "second" && tc.Skipped == nil {
+			t.Errorf("test with a failed dependency has no skipped element")
+		}
+	}
+}
+
+func TestJUnitReporterFileError(t *testing.T) {
+	j := NewJUnitReporterFile(filepath.Join(t.TempDir(), "missing", "report.xml"))
+	if err := j.write(&junitTestSuites{}); err == nil {
+		t.Errorf("no error writing to a directory that doesn't exist")
+	}
+}
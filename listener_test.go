@@ -0,0 +1,202 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingReporter records the listener events and the calls of
+// PerformManagerStatistics()
+type recordingReporter struct {
+	mutex  sync.Mutex
+	name   string
+	events []string
+	panics bool // PerformManagerStatistics() panics after it is complete
+}
+
+func (r *recordingReporter) record(format string, args ...interface{}) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingReporter) Name() string {
+	if r.name == "" {
+		return "recordingReporter"
+	}
+	return r.name
+}
+
+func (r *recordingReporter) Init(parent Manager) {}
+
+func (r *recordingReporter) PerformManagerStatistics(report *ManagerResult, name, msg string, complete chan int) {
+	r.record("statistics %s", name)
+	complete <- 1
+	if r.panics {
+		panic("reporter broke")
+	}
+}
+
+func (r *recordingReporter) ManagerStarted(name string) {
+	r.record("manager %s", name)
+}
+
+func (r *recordingReporter) SuiteStarted(suiteName string) {
+	r.record("suite started %s", suiteName)
+}
+
+func (r *recordingReporter) SuiteFinished(result suiteResult) {
+	r.record("suite finished %s %s", result.Name(), SuiteStatusName(result.Status))
+}
+
+func (r *recordingReporter) TestStarted(suiteName, testName string) {
+	r.record("test started %s/%s", suiteName, testName)
+}
+
+func (r *recordingReporter) TestFinished(suiteName string, result testResult) {
+	r.record("test finished %s/%s %s", suiteName, result.Name(), TcStatusName(result.Status))
+}
+
+func (r *recordingReporter) CheckpointLogged(suiteName, testName string, level int, msg string) {
+	r.record("checkpoint %s/%s %d %s", suiteName, testName, level, msg)
+}
+
+type checkingTest struct {
+	TestCase
+}
+
+func (t *checkingTest) Run() (int, error) {
+	t.Check(1, "value is %d", 1)
+	return t.ReturnFromRun()
+}
+
+func TestListenerEvents(t *testing.T) {
+	r := &recordingReporter{}
+	tm := NewManager(ioutil.Discard, r, SuiteSerial, TcSerial)
+	suite := NewSuite("suite1", &tm, Parameters{})
+	suite.AddTest(&checkingTest{}, "test1", Parameters{})
+	suite.AddTest(&checkTest{checks: []bool{false}}, "test2", Parameters{})
+	tm.AddSuite(suite)
+	tm.RunAll()
+
+	// results of tests are reported in the background, so only the order
+	// of the events of each test and of the manager and suite is known
+	for test, want := range map[string][]string{
+		"": {
+			"manager Test Manager",
+			"suite started suite1",
+			"suite finished suite1 Passed",
+			"statistics Test Manager",
+		},
+		"test1": {
+			"test started suite1/test1",
+			fmt.Sprintf("checkpoint suite1/test1 %d CHECK::PASS::value is 1", ResultPass),
+			"test finished suite1/test1 Passed",
+		},
+		"test2": {
+			"test started suite1/test2",
+			fmt.Sprintf("checkpoint suite1/test2 %d check 0 failed", ResultFail),
+			"test finished suite1/test2 Failed",
+		},
+	} {
+		if events := eventsOf(r.events, test); !reflect.DeepEqual(events, want) {
+			t.Errorf("events of %q:\n%q\nwant:\n%q", test, events, want)
+		}
+	}
+}
+
+// eventsOf returns the events of test in order, or the events of no test
+// when test is ""
+func eventsOf(events []string, test string) []string {
+	found := []string{}
+	for _, event := range events {
+		isTest := strings.Contains(event, "/")
+		if (test == "" && !isTest) || (test != "" && strings.Contains(event+" ", "/"+test+" ")) {
+			found = append(found, event)
+		}
+	}
+	return found
+}
+
+// panickingListener panics in every event
+type panickingListener struct {
+	recordingReporter
+}
+
+func (p *panickingListener) TestStarted(suiteName, testName string) {
+	panic("listener broke")
+}
+
+func TestListenerPanic(t *testing.T) {
+	r := &recordingReporter{}
+	tm := NewManager(ioutil.Discard, &panickingListener{}, SuiteSerial, TcSerial)
+	tm.AddReporter(r)
+	suite := NewSuite("suite1", &tm, Parameters{})
+	suite.AddTest(&checkTest{checks: []bool{true}}, "test1", Parameters{})
+	tm.AddSuite(suite)
+	tm.RunAll()
+
+	if tm.report.reportStats.TotalNumberOfTestCasesPassed != 1 {
+		t.Errorf("a panic in a listener stopped the test")
+	}
+	found := false
+	for _, event := range r.events {
+		if event == "test finished suite1/test1 Passed" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("other listeners didn't get the events: %q", r.events)
+	}
+}
+
+// removingListener removes itself from the manager when a test finishes
+type removingListener struct {
+	recordingReporter
+	tm *TestManager
+}
+
+func (l *removingListener) TestFinished(suiteName string, result testResult) {
+	l.record("test finished %s/%s", suiteName, result.Name())
+	l.tm.RemoveReporter(l)
+	l.tm.Reporters()
+}
+
+func TestListenerCallsManager(t *testing.T) {
+	tm := NewManager(ioutil.Discard, nil, SuiteSerial, TcSerial)
+	l := &removingListener{tm: &tm}
+	tm.AddReporter(l)
+	suite := NewSuite("suite1", &tm, Parameters{})
+	suite.AddTest(&checkTest{checks: []bool{true}}, "test1", Parameters{})
+	suite.AddTest(&checkTest{checks: []bool{true}}, "test2", Parameters{})
+	tm.AddSuite(suite)
+
+	done := make(chan bool)
+	go func() {
+		tm.RunAll()
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunAll blocked on a listener that called RemoveReporter")
+	}
+	// events of test2 sent before the listener was removed may follow
+	if len(eventsOf(l.events, "test1")) != 3 {
+		t.Errorf("events:\n%q", l.events)
+	}
+	for _, reporter := range tm.Reporters() {
+		if reporter == l {
+			t.Errorf("listener wasn't removed")
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ManagerHook is run once around all suites by RunAll(). Setup() returns
+// ManagerSetupFailed to fail the run, which skips all suites. Teardown()
+// returns ManagerTeardownFailed to fail the run. A returned error or a panic
+// is reported as ManagerSetupError or ManagerTeardownError
+type ManagerHook interface {
+	Setup() (status int, msg string, err error)
+	Teardown() (status int, msg string, err error)
+}
+
+// HookRegister is implemented by a TestRegister, like DefaultRegister,
+// that creates the ManagerHook objects for <Hook> elements of a test plan
+type HookRegister interface {
+	GetHook(hookClass string, tm *TestManager, params Parameters) (ManagerHook, error)
+}
+
+// hookInitializer is implemented by hooks that take the parameters of
+// their <Hook> element
+type hookInitializer interface {
+	Init(parent Manager, params Parameters)
+}
+
+// GetHook creates the ManagerHook registered as hookClass in Hooks and
+// calls its Init(tm, params) if it has one
+func (r *DefaultRegister) GetHook(hookClass string, tm *TestManager, params Parameters) (ManagerHook, error) {
+	hookType, ok := r.Hooks[hookClass]
+	if !ok {
+		return nil, fmt.Errorf("invalid hook class '%s'", hookClass)
+	}
+	hook, ok := reflect.New(hookType).Interface().(ManagerHook)
+	if !ok {
+		return nil, fmt.Errorf("hook class '%s' does not implement ManagerHook", hookClass)
+	}
+	if h, ok := hook.(hookInitializer); ok {
+		h.Init(tm, params)
+	}
+	return hook, nil
+}
+
+// HasHook returns true if hookClass is in Hooks
+func (r *DefaultRegister) HasHook(hookClass string) bool {
+	_, ok := r.Hooks[hookClass]
+	return ok
+}
+
+// AddHook adds a ManagerHook to run around all suites. Setup() of hooks
+// runs in the order they were added, Teardown() in reverse order
+func (tm *TestManager) AddHook(hook ManagerHook) {
+	tm.hooks = append(tm.hooks, hook)
+}
+
+// Hooks returns the hooks added by AddHook()
+func (tm *TestManager) Hooks() []ManagerHook {
+	return append([]ManagerHook{}, tm.hooks...)
+}
+
+// hookResult is the outcome of running the Setup() or Teardown() of hooks
+type hookResult struct {
+	status int // ManagerPassed or a Manager* failure code
+	msg    string
+}
+
+// setupHooks runs Setup() of the hooks until one doesn't pass and returns
+// the number of hooks that were set up, which need a Teardown()
+func (tm *TestManager) setupHooks() (int, hookResult) {
+	for i, hook := range tm.hooks {
+		status, msg, err := tm.runHook(hook.Setup)
+		switch {
+		case err != nil:
+			return i + 1, hookResult{ManagerSetupError, fmt.Sprintf("Error caught During Manager Setup::%s", err.Error())}
+		case status == ManagerSetupFailed:
+			return i + 1, hookResult{ManagerSetupFailed, msg}
+		}
+	}
+	return len(tm.hooks), hookResult{status: ManagerPassed}
+}
+
+// teardownHooks runs Teardown() of the first count hooks in reverse order.
+// All of them run even when one doesn't pass; the first failure is returned
+func (tm *TestManager) teardownHooks(count int) hookResult {
+	result := hookResult{status: ManagerPassed}
+	for i := count - 1; i >= 0; i-- {
+		status, msg, err := tm.runHook(tm.hooks[i].Teardown)
+		if result.status != ManagerPassed {
+			continue
+		}
+		switch {
+		case err != nil:
+			result = hookResult{ManagerTeardownError, fmt.Sprintf("Error caught During Manager Teardown::%s", err.Error())}
+		case status == ManagerTeardownFailed:
+			result = hookResult{ManagerTeardownFailed, msg}
+		}
+	}
+	return result
+}
+
+// runHook calls fn and returns a panic as error
+func (tm *TestManager) runHook(fn func() (int, string, error)) (status int, msg string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return fn()
+}
+
+// skipSuite reports suiteName and all of its tests as skipped with msg
+// without running Setup() or Teardown() of the suite. When testReasons is
+// not nil, it has the reason for each test instead of msg
+func (tm *TestManager) skipSuite(suiteName, msg string, testReasons []string, chSuiteResults chan int) {
+	suite := tm.GetSuite(suiteName)
+	chComplete := make(chan int, 1)
+	chReport := make(chan testResult)
+	go tm.testResultHandler(suiteName, chReport, chComplete)
+
+	tm.report.suiteStarted(suiteName, "")
+	for i, tc := range suite.GetTestCases() {
+		reason := msg
+		if testReasons != nil {
+			reason = testReasons[i]
+		}
+		tm.skipTest(suiteName, tc, reason, chReport)
+	}
+	close(chReport)
+	_ = <-chComplete
+	tm.report.suiteSkipped(suiteName, msg)
+	chSuiteResults <- SuiteSkipped
+}
+
+// addXMLHooks creates the hooks of the test plan with registry and adds them
+func (tm *TestManager) addXMLHooks(testPlan *XMLTestPlan, registry TestRegister, mngrParams *Parameters, overrides []paramOverride) error {
+	if len(testPlan.Hooks) == 0 {
+		return nil
+	}
+	hookRegistry, ok := registry.(HookRegister)
+	if !ok {
+		return fmt.Errorf("registry does not support manager hooks")
+	}
+	for i, xmlHook := range testPlan.Hooks {
+		hookSource := ParamSource{Kind: ParamFromHook, Location: fmt.Sprintf("/TestManager/Hook[%d]", i+1)}
+		hookParams, _ := resolveParams(xmlHook.Params, hookSource, mngrParams, overrides, "")
+		if err := applyOverrides(hookParams, overrides, ""); err != nil {
+			return err
+		}
+		hook, err := hookRegistry.GetHook(strings.TrimSpace(xmlHook.Class), tm, *hookParams)
+		if err != nil {
+			return err
+		}
+		tm.AddHook(hook)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// orderHook records its Setup() and Teardown() calls in a shared slice
+type orderHook struct {
+	name     string
+	calls    *[]string
+	setup    int
+	teardown int
+	err      error
+	panics   bool
+}
+
+func (h *orderHook) Setup() (int, string, error) {
+	*h.calls = append(*h.calls, "setup "+h.name)
+	if h.panics {
+		panic("hook broke")
+	}
+	return h.setup, h.name + " setup", h.err
+}
+
+func (h *orderHook) Teardown() (int, string, error) {
+	*h.calls = append(*h.calls, "teardown "+h.name)
+	return h.teardown, h.name + " teardown", nil
+}
+
+// paramHook is created by a DefaultRegister and keeps its parameters
+type paramHook struct {
+	orderHook
+	params Parameters
+}
+
+func (h *paramHook) Init(parent Manager, params Parameters) {
+	h.params = params
+}
+
+func TestManagerHookOrder(t *testing.T) {
+	calls := []string{}
+	tm := newTestManager()
+	tm.AddHook(&orderHook{name: "first", calls: &calls})
+	tm.AddHook(&orderHook{name: "second", calls: &calls})
+	suite := addCountingSuite(tm, "suite1", "test1")
+	tm.RunAll()
+	test := suite.GetTestCase("test1").(*countingTest)
+
+	want := []string{"setup first", "setup second", "teardown second", "teardown first"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("hook calls %q, want %q", calls, want)
+	}
+	if test.runs != 1 || tm.report.Status != ManagerPassed {
+		t.Errorf("test ran %d times, manager %s", test.runs, ManagerStatusName(tm.report.Status))
+	}
+}
+
+func TestManagerHookSetupFailed(t *testing.T) {
+	calls := []string{}
+	r := &recordingReporter{}
+	tm := newTestManager(r)
+	tm.AddHook(&orderHook{name: "first", calls: &calls})
+	tm.AddHook(&orderHook{name: "second", calls: &calls, setup: ManagerSetupFailed})
+	tm.AddHook(&orderHook{name: "third", calls: &calls})
+	suite := addCountingSuite(tm, "suite1", "test1")
+	tm.RunAll()
+	test := suite.GetTestCase("test1").(*countingTest)
+
+	want := []string{"setup first", "setup second", "teardown second", "teardown first"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("hook calls %q, want %q", calls, want)
+	}
+	if test.runs != 0 || !hasEvent(r.events, "suite finished suite1 Skipped") {
+		t.Errorf("suite wasn't skipped: %q", r.events)
+	}
+	if tm.report.Status != ManagerSetupFailed || tm.report.StatusMessage != "second setup" {
+		t.Errorf("manager is %s: %s", ManagerStatusName(tm.report.Status), tm.report.StatusMessage)
+	}
+}
+
+func TestManagerHookErrors(t *testing.T) {
+	calls := []string{}
+	tm := newTestManager()
+	tm.AddHook(&orderHook{name: "broken", calls: &calls, panics: true})
+	addCountingSuite(tm, "suite1", "test1")
+	tm.RunAll()
+	if tm.report.Status != ManagerSetupError || !strings.Contains(tm.report.StatusMessage, "hook broke") {
+		t.Errorf("panic in Setup() is %s: %s", ManagerStatusName(tm.report.Status), tm.report.StatusMessage)
+	}
+
+	calls = []string{}
+	tm = newTestManager()
+	tm.AddHook(&orderHook{name: "first", calls: &calls, teardown: ManagerTeardownFailed})
+	tm.AddHook(&orderHook{name: "second", calls: &calls, err: errors.New("no database")})
+	addCountingSuite(tm, "suite1", "test1")
+	tm.RunAll()
+	if tm.report.Status != ManagerSetupError || !strings.Contains(tm.report.StatusMessage, "no database") {
+		t.Errorf("Setup() error is %s: %s", ManagerStatusName(tm.report.Status), tm.report.StatusMessage)
+	}
+	if calls[len(calls)-1] != "teardown first" {
+		t.Errorf("hooks that were set up weren't torn down: %q", calls)
+	}
+
+	calls = []string{}
+	tm = newTestManager()
+	tm.AddHook(&orderHook{name: "first", calls: &calls, teardown: ManagerTeardownFailed})
+	tm.AddHook(&orderHook{name: "second", calls: &calls, teardown: ManagerTeardownFailed})
+	addCountingSuite(tm, "suite1", "test1")
+	tm.RunAll()
+	if tm.report.Status != ManagerTeardownFailed || tm.report.StatusMessage != "second teardown" {
+		t.Errorf("manager is %s: %s", ManagerStatusName(tm.report.Status), tm.report.StatusMessage)
+	}
+	if len(calls) != 4 {
+		t.Errorf("a failed Teardown() stopped the others: %q", calls)
+	}
+}
+
+func TestGetHook(t *testing.T) {
+	tm := newTestManager()
+	register := &DefaultRegister{Hooks: map[string]reflect.Type{"ParamHook": reflect.TypeOf(paramHook{})}}
+	params := Parameters{}
+	params.AddParam("database", "test", "")
+	hook, err := register.GetHook("ParamHook", tm, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := hook.(*paramHook).params.GetParamValue("database"); value != "test" {
+		t.Errorf("hook wasn't initialized with its parameters")
+	}
+
+	if _, err := register.GetHook("Missing", tm, params); err == nil || err.Error() != "invalid hook class 'Missing'" {
+		t.Errorf("want an invalid hook class error, got %v", err)
+	}
+	register.Hooks["NotAHook"] = reflect.TypeOf(testResult{})
+	if _, err := register.GetHook("NotAHook", tm, params); err == nil || !strings.Contains(err.Error(), "does not implement ManagerHook") {
+		t.Errorf("want a ManagerHook error, got %v", err)
+	}
+}
@@ -0,0 +1,405 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParamFromMatrix is the ParamSource kind of the values a matrix test
+// was generated with
+const ParamFromMatrix = "matrix"
+
+// ---------------------------  Define matrix for test plans -------------------
+
+// XMLMatrix expands a test case into one test for each combination of the
+// values of its axes, the cartesian product, followed by one test for each
+// explicit case:
+//
+//	<TestCase name="sweep" class="test1">
+//		<Matrix>
+//			<Axis name="voltage" type="float">3.0, 3.3, 5.0</Axis>
+//			<Axis name="temp" type="int">-20, 25, 85</Axis>
+//			<Case>
+//				<Param name="voltage" type="float">1.8</Param>
+//				<Param name="temp" type="int">25</Param>
+//			</Case>
+//		</Matrix>
+//	</TestCase>
+//
+// In JSON and YAML plans axis values are a list and a case is an object
+// of param names and values
+type XMLMatrix struct {
+	Axes  []XMLAxis       `xml:"Axis" json:"axes,omitempty" yaml:"axes,omitempty"`
+	Cases []XMLMatrixCase `xml:"Case" json:"cases,omitempty" yaml:"cases,omitempty"`
+}
+
+// XMLAxis is a param with the list of values a matrix test runs with.
+// Type is the type of each value, values without type are strings
+type XMLAxis struct {
+	Name   string `xml:"name,attr" json:"name" yaml:"name"`
+	Type   string `xml:"type,attr,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
+	Values string `xml:",chardata" json:"values" yaml:"values"` // comma separated or JSON array
+}
+
+// XMLMatrixCase is one explicit combination of matrix values
+type XMLMatrixCase struct {
+	Params []XMLParam `xml:"Param" json:"params" yaml:"params"`
+}
+
+// planAxis is XMLAxis with values of any JSON or YAML type
+type planAxis struct {
+	Name   string      `json:"name" yaml:"name"`
+	Type   string      `json:"type" yaml:"type"`
+	Values interface{} `json:"values" yaml:"values"`
+}
+
+// set copies a to axis with the values as string. A list is kept as JSON
+func (a *planAxis) set(axis *XMLAxis) error {
+	axis.Name = a.Name
+	axis.Type = a.Type
+	switch v := a.Values.(type) {
+	case nil:
+		axis.Values = ""
+	case string:
+		axis.Values = v
+	case []interface{}:
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("axis '%s' values %v can't be used: %s", a.Name, v, err.Error())
+		}
+		axis.Values = string(buf)
+	default:
+		axis.Values = fmt.Sprint(v)
+	}
+	return nil
+}
+
+// UnmarshalJSON reads an axis with a list of values of any JSON type
+func (axis *XMLAxis) UnmarshalJSON(data []byte) error {
+	var a planAxis
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&a); err != nil {
+		return err
+	}
+	return a.set(axis)
+}
+
+// UnmarshalYAML reads an axis with a list of values of any YAML type
+func (axis *XMLAxis) UnmarshalYAML(value *yaml.Node) error {
+	var a planAxis
+	if err := value.Decode(&a); err != nil {
+		return err
+	}
+	return a.set(axis)
+}
+
+// setCase copies the param names and values of a JSON or YAML case to c.
+// Types are taken from the values, like params without type
+func (c *XMLMatrixCase) setCase(values map[string]interface{}) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	c.Params = make([]XMLParam, len(names))
+	for i, name := range names {
+		p := planParam{Name: name, Value: values[name]}
+		if err := p.set(&c.Params[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON reads a case as an object of param names and values
+func (c *XMLMatrixCase) UnmarshalJSON(data []byte) error {
+	values := map[string]interface{}{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&values); err != nil {
+		return err
+	}
+	return c.setCase(values)
+}
+
+// UnmarshalYAML reads a case as a mapping of param names and values
+func (c *XMLMatrixCase) UnmarshalYAML(value *yaml.Node) error {
+	values := map[string]interface{}{}
+	if err := value.Decode(&values); err != nil {
+		return err
+	}
+	return c.setCase(values)
+}
+
+// caseValues returns the params of c as values for JSON and YAML. Numbers
+// and bools keep their type, other values are written as strings
+func (c XMLMatrixCase) caseValues() map[string]interface{} {
+	values := make(map[string]interface{}, len(c.Params))
+	for _, param := range c.Params {
+		values[param.Name] = param.Value
+		switch param.Type {
+		case "int", "float", "bool":
+			if value, err := parseParamValue(param.Value, param.Type); err == nil {
+				values[param.Name] = value
+			}
+		}
+	}
+	return values
+}
+
+// MarshalJSON writes a case as an object of param names and values
+func (c XMLMatrixCase) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.caseValues())
+}
+
+// MarshalYAML writes a case as a mapping of param names and values
+func (c XMLMatrixCase) MarshalYAML() (interface{}, error) {
+	return c.caseValues(), nil
+}
+
+// values converts the values of the axis to its type
+func (axis XMLAxis) values() ([]interface{}, error) {
+	if axis.Type != "" {
+		list, err := parseListParam(axis.Values, axis.Type)
+		if err != nil {
+			return nil, err
+		}
+		return toList(list)
+	}
+	text := strings.TrimSpace(axis.Values)
+	if !strings.HasPrefix(text, "[") {
+		return toList(text)
+	}
+	// an untyped list from a JSON or YAML plan keeps the type of each value
+	var items []interface{}
+	if err := decodeJSONNumbers(text, &items); err != nil {
+		return nil, fmt.Errorf("invalid values '%s': %s", axis.Values, err.Error())
+	}
+	for i, item := range items {
+		items[i] = jsonValue(item)
+	}
+	return items, nil
+}
+
+// matrix converts m to a Matrix. The errors found have locations under
+// location, the /Matrix element of the test case
+func (m *XMLMatrix) matrix(location string) (Matrix, PlanErrors) {
+	matrix := Matrix{}
+	var errs PlanErrors
+	addError := func(location, format string, args ...interface{}) {
+		errs = append(errs, PlanError{Location: location, Message: fmt.Sprintf(format, args...)})
+	}
+
+	seen := map[string]int{}
+	for _, xmlAxis := range m.Axes {
+		axisLocation := nthLocation(location, "Axis", xmlAxis.Name, seen)
+		if xmlAxis.Name == "" {
+			addError(axisLocation, "axis has no name")
+		} else if seen[xmlAxis.Name] > 1 {
+			addError(axisLocation, "duplicate axis '%s'", xmlAxis.Name)
+		}
+		values, err := xmlAxis.values()
+		if err != nil {
+			addError(axisLocation, "%s", err.Error())
+		} else if len(values) == 0 {
+			addError(axisLocation, "axis has no values")
+		}
+		matrix.Axes = append(matrix.Axes, MatrixAxis{Name: xmlAxis.Name, Values: values})
+	}
+
+	for i, xmlCase := range m.Cases {
+		caseLocation := fmt.Sprintf("%s/Case[%d]", location, i+1)
+		if len(xmlCase.Params) == 0 {
+			addError(caseLocation, "case has no params")
+		}
+		values := map[string]interface{}{}
+		for _, param := range xmlCase.Params {
+			paramLocation := planLocation(caseLocation, "Param", param.Name)
+			value, err := parseParamValue(param.Value, param.Type)
+			switch {
+			case param.Name == "":
+				addError(caseLocation, "param has no name")
+			case err != nil:
+				addError(paramLocation, "%s", err.Error())
+			default:
+				values[param.Name] = value
+			}
+		}
+		matrix.Cases = append(matrix.Cases, values)
+	}
+
+	if len(m.Axes) == 0 && len(m.Cases) == 0 {
+		addError(location, "matrix has no axes or cases")
+	}
+	return matrix, errs
+}
+
+// ---------------------------  Matrix -------------------
+
+// Matrix is the param values a test is run with by AddMatrixTest(). Each
+// combination of the values of Axes is run, then each of Cases
+type Matrix struct {
+	Axes  []MatrixAxis
+	Cases []map[string]interface{} // param name to value
+}
+
+// MatrixAxis is a param with the values a matrix test runs with
+type MatrixAxis struct {
+	Name   string
+	Values []interface{}
+}
+
+// MatrixValue is the value of a param for one test of a matrix, or one
+// column of a data file row
+type MatrixValue struct {
+	Name  string
+	Value interface{}
+}
+
+// Expand returns the combinations of values of the matrix in run order.
+// The last axis changes fastest, like nested loops over the axes. The
+// values of a case are in axis order, followed by other params by name
+func (m Matrix) Expand() [][]MatrixValue {
+	combinations := [][]MatrixValue{}
+	if len(m.Axes) > 0 {
+		combinations = [][]MatrixValue{{}}
+		for _, axis := range m.Axes {
+			next := make([][]MatrixValue, 0, len(combinations)*len(axis.Values))
+			for _, combination := range combinations {
+				for _, value := range axis.Values {
+					values := append([]MatrixValue{}, combination...)
+					next = append(next, append(values, MatrixValue{Name: axis.Name, Value: value}))
+				}
+			}
+			combinations = next
+		}
+	}
+
+	for _, c := range m.Cases {
+		values := make([]MatrixValue, 0, len(c))
+		for _, axis := range m.Axes {
+			if value, ok := c[axis.Name]; ok {
+				values = append(values, MatrixValue{Name: axis.Name, Value: value})
+			}
+		}
+		names := []string{}
+		for name := range c {
+			if !m.isAxis(name) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			values = append(values, MatrixValue{Name: name, Value: c[name]})
+		}
+		combinations = append(combinations, values)
+	}
+	return combinations
+}
+
+func (m Matrix) isAxis(name string) bool {
+	for _, axis := range m.Axes {
+		if axis.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// MatrixTestName returns the name of the test generated from test name
+// for values, like "sweep(voltage=3.3,temp=25)"
+func MatrixTestName(name string, values []MatrixValue) string {
+	pairs := make([]string, 0, len(values))
+	for _, v := range values {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", v.Name, formatParamValue(v.Value)))
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(pairs, ","))
+}
+
+// matrixParams returns parent with the values of one matrix test added
+// from source
+func matrixParams(values []MatrixValue, source ParamSource, parent *Parameters) *Parameters {
+	params := new(Parameters)
+	params.Init()
+	for _, v := range values {
+		params.addFrom(v.Name, v.Value, "", source)
+	}
+	if parent != nil {
+		params.inherit(parent)
+	}
+	return params
+}
+
+// matrixValues returns the params of a test that were set by its matrix
+func matrixValues(params *Parameters) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, name := range params.Names() {
+		if param, ok := params.GetParam(name); ok && param.Source().Kind == ParamFromMatrix {
+			values[name] = param.Value()
+		}
+	}
+	return values
+}
+
+// ---------------------------  Matrix suites -------------------
+
+// matrixSuite is implemented by suites, like DefaultSuite, that run tests
+// generated from a matrix or data file
+type matrixSuite interface {
+	MatrixTests(name string) []string
+	addMatrixTest(name, testName string)
+}
+
+// matrixChecker is implemented by a TestRegister, like DefaultRegister, that
+// can tell ValidateTestPlan() if a suite class runs matrix or data tests
+type matrixChecker interface {
+	hasMatrixSuite(suiteClass string) bool
+}
+
+// hasMatrixSuite returns true for DefaultSuite and the types in Suites that
+// run matrix and data tests, like types that embed DefaultSuite
+func (r *DefaultRegister) hasMatrixSuite(suiteClass string) bool {
+	if suiteType, ok := r.Suites[suiteClass]; ok {
+		return reflect.PtrTo(suiteType).Implements(reflect.TypeOf((*matrixSuite)(nil)).Elem())
+	}
+	return r.HasSuite(suiteClass)
+}
+
+// AddMatrixTest adds a test created by newTest for each combination of
+// values of matrix, named by MatrixTestName(). The values replace params
+// with the same name. Dependencies on name, see AddDependency(), are on
+// all the generated tests
+func (s *DefaultSuite) AddMatrixTest(newTest func() Tester, name string, params Parameters, matrix Matrix) Suite {
+	source := ParamSource{Kind: ParamFromMatrix, Location: name}
+	for _, values := range matrix.Expand() {
+		testName := MatrixTestName(name, values)
+		s.AddTest(newTest(), testName, *matrixParams(values, source, &params))
+		s.addMatrixTest(name, testName)
+	}
+	return s
+}
+
+// MatrixTests returns the names of the tests generated from matrix or data
+// test name
+func (s *DefaultSuite) MatrixTests(name string) []string {
+	return s.matrixTests[name]
+}
+
+func (s *DefaultSuite) addMatrixTest(name, testName string) {
+	if s.matrixTests == nil {
+		s.matrixTests = map[string][]string{}
+	}
+	s.matrixTests[name] = append(s.matrixTests[name], testName)
+}
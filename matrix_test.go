@@ -0,0 +1,176 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testNames returns the names of the tests of suite in order
+func testNames(suite Suite) []string {
+	names := []string{}
+	for _, tc := range suite.GetTestCases() {
+		names = append(names, tc.Name())
+	}
+	return names
+}
+
+func TestMatrixExpand(t *testing.T) {
+	matrix := Matrix{
+		Axes: []MatrixAxis{
+			{Name: "voltage", Values: []interface{}{3.0, 3.3, 5.0}},
+			{Name: "temp", Values: []interface{}{int64(-20), int64(85)}},
+		},
+		Cases: []map[string]interface{}{{"temp": int64(25), "voltage": 1.8, "mode": "low"}},
+	}
+	names := []string{}
+	for _, values := range matrix.Expand() {
+		names = append(names, MatrixTestName("sweep", values))
+	}
+	want := []string{
+		"sweep(voltage=3,temp=-20)",
+		"sweep(voltage=3,temp=85)",
+		"sweep(voltage=3.3,temp=-20)",
+		"sweep(voltage=3.3,temp=85)",
+		"sweep(voltage=5,temp=-20)",
+		"sweep(voltage=5,temp=85)",
+		"sweep(voltage=1.8,temp=25,mode=low)",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("names %q, want %q", names, want)
+	}
+	if n := len(Matrix{Cases: matrix.Cases}.Expand()); n != 1 {
+		t.Errorf("matrix with only a case has %d tests", n)
+	}
+}
+
+func TestAddMatrixTest(t *testing.T) {
+	tm := newTestManager()
+	suite := NewSuite("suite1", tm, Parameters{})
+	params := Parameters{}
+	params.AddParam("mode", "fast", "")
+	params.AddParam("size", 1, "")
+	matrix := Matrix{Axes: []MatrixAxis{{Name: "size", Values: []interface{}{10, 20}}}}
+	suite.AddMatrixTest(func() Tester { return &countingTest{} }, "sized", params, matrix)
+	suite.AddTest(&countingTest{}, "report", Parameters{})
+	suite.AddDependency("report", "sized")
+	tm.AddSuite(suite)
+
+	if names := suite.MatrixTests("sized"); !reflect.DeepEqual(names, []string{"sized(size=10)", "sized(size=20)"}) {
+		t.Errorf("matrix tests %q", names)
+	}
+	tc := suite.GetTestCase("sized(size=20)")
+	if size, _ := tc.(paramHolder).GetParams().GetInt("size"); size != 20 {
+		t.Errorf("size of the generated test is %d", size)
+	}
+	if mode, _ := tc.(paramHolder).GetParams().GetString("mode"); mode != "fast" {
+		t.Errorf("generated test doesn't have the other params")
+	}
+	graph, err := newTestGraph(suite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deps := graph.dependsOn[2]; !reflect.DeepEqual(deps, []int{0, 1}) {
+		t.Errorf("test depending on a matrix test waits for %v", deps)
+	}
+}
+
+func TestPlanMatrix(t *testing.T) {
+	plan := parseXMLPlan(t, `
+<TestManager name="Manager">
+  <TestSuite name="suite1">
+    <TestCase name="sweep" class="counting">
+      <Param name="label">v=${voltage}</Param>
+      <Matrix>
+        <Axis name="voltage" type="float">3.0, 3.3</Axis>
+        <Axis name="temp" type="int">-20, 25, 85</Axis>
+        <Case><Param name="voltage" type="float">1.8</Param></Case>
+      </Matrix>
+    </TestCase>
+  </TestSuite>
+</TestManager>`)
+	tm := newTestManager()
+	if err := tm.AddTestPlan(plan, validateRegister()); err != nil {
+		t.Fatal(err)
+	}
+	names := testNames(tm.GetSuite("suite1"))
+	if len(names) != 7 || names[0] != "sweep(voltage=3,temp=-20)" || names[6] != "sweep(voltage=1.8)" {
+		t.Errorf("generated tests %q", names)
+	}
+	params := planTestParams(tm, "suite1", "sweep(voltage=3.3,temp=85)")
+	if label, _ := params.GetString("label"); label != "v=3.3" {
+		t.Errorf("label is %q", label)
+	}
+	if temp, _ := params.GetParam("temp"); temp.Value() != int64(85) || temp.Source().Kind != ParamFromMatrix {
+		t.Errorf("temp is %#v from %s", temp.Value(), temp.Source())
+	}
+
+	yamlFile := filepath.Join(t.TempDir(), "plan.yaml")
+	content := `
+name: Manager
+suites:
+  - name: suite1
+    tests:
+      - name: sweep
+        class: counting
+        matrix:
+          axes:
+            - {name: voltage, values: [3.0, 3.3]}
+            - {name: mode, values: [fast, slow]}
+          cases:
+            - {voltage: 1.8, mode: low}
+`
+	if err := ioutil.WriteFile(yamlFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tm = newTestManager()
+	var yamlPlan XMLTestPlan
+	if err := tm.ParseTestPlan(yamlFile, &yamlPlan); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.AddTestPlan(&yamlPlan, validateRegister()); err != nil {
+		t.Fatal(err)
+	}
+	if names := testNames(tm.GetSuite("suite1")); len(names) != 5 || names[4] != "sweep(voltage=1.8,mode=low)" {
+		t.Errorf("generated tests %q", names)
+	}
+}
+
+func TestPlanMatrixErrors(t *testing.T) {
+	plan := parseXMLPlan(t, `
+<TestManager name="Manager">
+  <TestSuite name="suite1">
+    <TestCase name="sweep" class="counting">
+      <Matrix>
+        <Axis name="voltage" type="float">3.0, high</Axis>
+        <Axis name="temp"></Axis>
+        <Axis name="temp">1</Axis>
+        <Case></Case>
+      </Matrix>
+    </TestCase>
+    <TestCase name="empty" class="counting"><Matrix></Matrix></TestCase>
+  </TestSuite>
+</TestManager>`)
+	err := newTestManager().ValidateTestPlan(plan, validateRegister())
+	if err == nil {
+		t.Fatal("no errors")
+	}
+	matrix := "/TestManager/TestSuite[@name='suite1']/TestCase[@name='sweep']/Matrix"
+	for _, want := range []string{
+		matrix + "/Axis[@name='voltage']: list element: invalid float value 'high'",
+		matrix + "/Axis[@name='temp']: axis has no values",
+		matrix + "/Axis[@name='temp'][2]: duplicate axis 'temp'",
+		matrix + "/Case[1]: case has no params",
+		"/TestManager/TestSuite[@name='suite1']/TestCase[@name='empty']/Matrix: matrix has no axes or cases",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("no error %q in:\n%s", want, err)
+		}
+	}
+}
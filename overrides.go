@@ -0,0 +1,281 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by AddTestPlan()
+const (
+	// ParamEnvPrefix starts the name of a variable that overrides a param,
+	// like GOQA_PARAM_OS or GOQA_PARAM_suite1__test1__OS. "__" separates
+	// the scope like "/" in the key of AddParamOverrides()
+	ParamEnvPrefix = "GOQA_PARAM_"
+
+	// ParamsFileEnv is the name of a params file read like AddParamsFile()
+	ParamsFileEnv = "GOQA_PARAMS_FILE"
+)
+
+// Priority of overrides with the same scope, the highest is applied last
+const (
+	overrideFromFile = iota
+	overrideFromEnv
+	overrideFromMap
+)
+
+// paramOverride replaces param name of a test plan for the tests in scope.
+// Empty suite is all suites and empty test all tests of the suite
+type paramOverride struct {
+	suite, test string
+	name        string
+	value       interface{}
+	source      ParamSource
+	priority    int
+}
+
+// specificity orders overrides so the ones for a suite are applied after
+// the manager wide ones, and the ones for a test after those for its suite
+func (o paramOverride) specificity() int {
+	switch {
+	case o.test != "":
+		return 2
+	case o.suite != "":
+		return 1
+	}
+	return 0
+}
+
+// applies reports if o is for the params of suiteName and a test named one
+// of testNames. A matrix test has its own name and the name of the test
+// it was generated from. Both are empty for the suite and manager params
+func (o paramOverride) applies(suiteName string, testNames ...string) bool {
+	if o.suite != "" && o.suite != suiteName {
+		return false
+	}
+	if o.test == "" {
+		return true
+	}
+	for _, testName := range testNames {
+		if o.test == testName {
+			return true
+		}
+	}
+	return false
+}
+
+// parseOverrideKey splits a key like "OS", "suite1/OS", or "suite1/test1/OS"
+func parseOverrideKey(key, sep string) (paramOverride, error) {
+	parts := strings.Split(key, sep)
+	for _, part := range parts {
+		if part == "" {
+			return paramOverride{}, fmt.Errorf("invalid param override '%s'", key)
+		}
+	}
+	switch len(parts) {
+	case 1:
+		return paramOverride{name: parts[0]}, nil
+	case 2:
+		return paramOverride{suite: parts[0], name: parts[1]}, nil
+	case 3:
+		return paramOverride{suite: parts[0], test: parts[1], name: parts[2]}, nil
+	}
+	return paramOverride{}, fmt.Errorf("invalid param override '%s', expected name, suite/name, or suite/test/name", key)
+}
+
+// AddParamOverrides sets params of the test plans added after it, on top of
+// the values from the plan. A key is a param name for all suites and tests,
+// "suite/name" for a suite and its tests, or "suite/test/name" for one test.
+// A value replacing a plan param is converted to the type of that param.
+// More specific keys win, and for the same key these overrides win over
+// GOQA_PARAM_* environment variables, which win over params files
+func (tm *TestManager) AddParamOverrides(overrides map[string]string) error {
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		o, err := parseOverrideKey(key, "/")
+		if err != nil {
+			return err
+		}
+		o.value = overrides[key]
+		o.source = ParamSource{Kind: ParamFromOverride, Location: key}
+		o.priority = overrideFromMap
+		tm.overrides = append(tm.overrides, o)
+	}
+	return nil
+}
+
+// AddParamsFile reads param overrides, with keys like AddParamOverrides(),
+// from fileName. A ".json", ".yaml", or ".yml" file is an object of keys and
+// values of any type, other files have "key=value" lines with "#" comments:
+//
+//	OS=Win64
+//	suite1/SuiteMaxTime=200
+//	suite1/test1/val3=hello there
+func (tm *TestManager) AddParamsFile(fileName string) error {
+	overrides, err := readParamsFile(fileName)
+	if err != nil {
+		return err
+	}
+	tm.overrides = append(tm.overrides, overrides...)
+	return nil
+}
+
+func readParamsFile(fileName string) ([]paramOverride, error) {
+	buf, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	if PlanFormat(fileName) != PlanFormatXML {
+		// YAML also reads JSON
+		if err := yaml.Unmarshal(buf, &values); err != nil {
+			return nil, fmt.Errorf("params file %s: %s", fileName, err.Error())
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(buf))
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			kv := strings.SplitN(text, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("params file %s:%d: expected key=value", fileName, line)
+			}
+			values[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	overrides := make([]paramOverride, 0, len(keys))
+	for _, key := range keys {
+		o, err := parseOverrideKey(key, "/")
+		if err != nil {
+			return nil, fmt.Errorf("params file %s: %s", fileName, err.Error())
+		}
+		o.value = values[key]
+		o.source = ParamSource{Kind: ParamFromFile, Location: fmt.Sprintf("%s %s", fileName, key)}
+		o.priority = overrideFromFile
+		overrides = append(overrides, o)
+	}
+	return overrides, nil
+}
+
+// envOverrides returns the overrides from GOQA_PARAM_* environment variables
+func envOverrides() ([]paramOverride, error) {
+	overrides := []paramOverride{}
+	for _, env := range os.Environ() {
+		kv := strings.SplitN(env, "=", 2)
+		if len(kv) != 2 || !strings.HasPrefix(kv[0], ParamEnvPrefix) {
+			continue
+		}
+		o, err := parseOverrideKey(strings.TrimPrefix(kv[0], ParamEnvPrefix), "__")
+		if err != nil {
+			return nil, fmt.Errorf("environment variable %s: %s", kv[0], err.Error())
+		}
+		o.value = kv[1]
+		o.source = ParamSource{Kind: ParamFromEnv, Location: kv[0]}
+		o.priority = overrideFromEnv
+		overrides = append(overrides, o)
+	}
+	return overrides, nil
+}
+
+// paramOverrides returns all overrides, with the params file from
+// GOQA_PARAMS_FILE and the GOQA_PARAM_* variables, in the order to apply them
+func (tm *TestManager) paramOverrides() ([]paramOverride, error) {
+	overrides := append([]paramOverride{}, tm.overrides...)
+	if fileName := os.Getenv(ParamsFileEnv); fileName != "" {
+		fileOverrides, err := readParamsFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, fileOverrides...)
+	}
+	env, err := envOverrides()
+	if err != nil {
+		return nil, err
+	}
+	overrides = append(overrides, env...)
+
+	sort.SliceStable(overrides, func(i, j int) bool {
+		if overrides[i].specificity() != overrides[j].specificity() {
+			return overrides[i].specificity() < overrides[j].specificity()
+		}
+		return overrides[i].priority < overrides[j].priority
+	})
+	return overrides, nil
+}
+
+// applyOverrides sets the overrides for suiteName and testNames in params.
+// testNames is empty for the params of the suite, and suiteName is empty too
+// for the manager params. A string value is converted to the type of the
+// param it replaces
+func applyOverrides(params *Parameters, overrides []paramOverride, suiteName string, testNames ...string) error {
+	for _, o := range overrides {
+		if !o.applies(suiteName, testNames...) {
+			continue
+		}
+		value := o.value
+		comment := ""
+		if old, ok := params.GetParam(o.name); ok {
+			comment = old.comment
+			if _, isString := value.(string); isString && old.value != nil {
+				converted, err := convertValue(value, reflect.TypeOf(old.value))
+				if err != nil {
+					return fmt.Errorf("param %s: %s", o.source, err.Error())
+				}
+				value = converted.Interface()
+			}
+		}
+		params.addFrom(o.name, value, comment, o.source)
+	}
+	return nil
+}
+
+// checkOverrides logs a warning for overrides of suites and tests that
+// are not in testPlan
+func (tm *TestManager) checkOverrides(testPlan *XMLTestPlan, overrides []paramOverride) {
+	tests := map[string]map[string]bool{}
+	for _, xmlSuite := range testPlan.Suites {
+		tests[xmlSuite.Name] = map[string]bool{}
+		for _, xmlTest := range xmlSuite.TestCases {
+			tests[xmlSuite.Name][xmlTest.Name] = true
+			generated, _ := testPlan.generatedTests(xmlTest, "")
+			for _, g := range generated {
+				tests[xmlSuite.Name][g.name] = true
+			}
+		}
+	}
+	for _, o := range overrides {
+		if o.suite == "" {
+			continue
+		}
+		if suiteTests, ok := tests[o.suite]; !ok || (o.test != "" && !suiteTests[o.test]) {
+			tm.log.LogWarning("Param %s is for a suite or test not in the test plan", o.source)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-QA/logger"
+)
+
+const overridePlan = `
+<TestManager name="Manager">
+  <Param name="count" type="int">1</Param>
+  <Param name="os">Win64</Param>
+  <TestSuite name="suite1">
+    <Param name="os">Linux</Param>
+    <TestCase name="test1" class="counting">
+      <Param name="os">Mac</Param>
+    </TestCase>
+    <TestCase name="test2" class="counting"/>
+  </TestSuite>
+</TestManager>`
+
+// planTestParams returns the params of test testName in suite suiteName
+func planTestParams(tm *TestManager, suiteName, testName string) *Parameters {
+	return tm.GetSuite(suiteName).GetTestCase(testName).(paramHolder).GetParams()
+}
+
+func TestParamOverrides(t *testing.T) {
+	paramsFile := filepath.Join(t.TempDir(), "params.yaml")
+	content := "suite1/test1/os: File\nsuite1/test2/retries: [1, 2]\n"
+	if err := ioutil.WriteFile(paramsFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ParamEnvPrefix+"count", "7")
+	t.Setenv(ParamEnvPrefix+"suite1__test1__os", "Env")
+
+	tm := newTestManager()
+	if err := tm.AddParamsFile(paramsFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.AddParamOverrides(map[string]string{"count": "5", "suite1/os": "BSD", "suite1/test2/os": "Solaris"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.AddTestPlan(parseXMLPlan(t, overridePlan), validateRegister()); err != nil {
+		t.Fatal(err)
+	}
+
+	test1 := planTestParams(tm, "suite1", "test1")
+	test2 := planTestParams(tm, "suite1", "test2")
+	for _, check := range []struct {
+		params *Parameters
+		name   string
+		value  interface{}
+		source string
+	}{
+		// the map wins over environment variables
+		{test1, "count", int64(5), "override count"},
+		// a test override wins over a suite override, and the environment over the params file
+		{test1, "os", "Env", "env GOQA_PARAM_suite1__test1__os"},
+		{test2, "os", "Solaris", "override suite1/test2/os"},
+		{test2, "retries", []interface{}{1, 2}, "file " + paramsFile + " suite1/test2/retries"},
+	} {
+		param, ok := check.params.GetParam(check.name)
+		if !ok {
+			t.Errorf("no param %s", check.name)
+			continue
+		}
+		if !strings.Contains(param.Describe(), check.source) || !reflect.DeepEqual(param.Value(), check.value) {
+			t.Errorf("%s is %#v from %s, want %#v from %s", check.name, param.Value(), param.Describe(), check.value, check.source)
+		}
+	}
+}
+
+func TestParamOverrideErrors(t *testing.T) {
+	tm := newTestManager()
+	for _, key := range []string{"suite1//os", "a/b/c/d"} {
+		if err := tm.AddParamOverrides(map[string]string{key: "x"}); err == nil {
+			t.Errorf("no error for key %q", key)
+		}
+	}
+
+	tm = newTestManager()
+	tm.AddParamOverrides(map[string]string{"count": "many"})
+	if err := tm.AddTestPlan(parseXMLPlan(t, overridePlan), validateRegister()); err == nil || !strings.Contains(err.Error(), "override count") {
+		t.Errorf("want an error for an override that can't be converted, got %v", err)
+	}
+
+	var log bytes.Buffer
+	tm = newTestManager()
+	tm.AddLogger("test", logger.LogLevelAll, &log)
+	tm.AddParamOverrides(map[string]string{"suite9/os": "x"})
+	if err := tm.AddTestPlan(parseXMLPlan(t, overridePlan), validateRegister()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(log.String(), "Param override suite9/os is for a suite or test not in the test plan") {
+		t.Errorf("no warning for an override of an unknown suite:\n%s", log.String())
+	}
+
+	file := filepath.Join(t.TempDir(), "params.txt")
+	if err := ioutil.WriteFile(file, []byte("# comment\nOS=Win64\nbroken\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.AddParamsFile(file); err == nil || !strings.Contains(err.Error(), "params.txt:3: expected key=value") {
+		t.Errorf("want a line error, got %v", err)
+	}
+}
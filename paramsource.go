@@ -0,0 +1,106 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of ParamSource, where the value of a param was set
+const (
+	ParamFromAPI      = "api"      // Go code, like AddParam()
+	ParamFromDefault  = "default"  // default value of InitParam(), like failureThreshold
+	ParamFromManager  = "manager"  // <Param> of the <TestManager> element of a test plan
+	ParamFromSuite    = "suite"    // <Param> of a <TestSuite> element
+	ParamFromTest     = "test"     // <Param> of a <TestCase> element
+	ParamFromHook     = "hook"     // <Param> of a <Hook> element
+	ParamFromOverride = "override" // override given to the run, like a command line option
+	ParamFromEnv      = "env"      // environment variable
+	ParamFromFile     = "file"     // params file
+)
+
+// ParamSource is where the value of a Parameter was set. Location is the
+// XPath style test plan element for params from a plan, like
+//
+//	/TestManager/TestSuite[@name='suite1']
+//
+// the name of the environment variable, or the key of the override with
+// the params file it is from
+type ParamSource struct {
+	Kind     string `json:"kind"`
+	Location string `json:"location,omitempty"`
+}
+
+func (s ParamSource) String() string {
+	if s.Location == "" {
+		return s.Kind
+	}
+	return fmt.Sprintf("%s %s", s.Kind, s.Location)
+}
+
+// Source returns where the value of the param was set
+func (p *Parameter) Source() ParamSource {
+	return p.source
+}
+
+// Overrides returns the params with the same name that this one replaced,
+// nearest first. A test param that replaced a suite param, which replaced a
+// manager param, returns the suite and the manager param
+func (p *Parameter) Overrides() []Parameter {
+	return append([]Parameter{}, p.overrides...)
+}
+
+// Describe returns the source of the param with the values it replaced, like
+//
+//	test /TestManager/TestSuite[@name='suite1']/TestCase[@name='test1'] (overrides suite /TestManager/TestSuite[@name='suite1'] = Linux)
+func (p *Parameter) Describe() string {
+	if len(p.overrides) == 0 {
+		return p.source.String()
+	}
+	chain := make([]string, 0, len(p.overrides))
+	for _, o := range p.overrides {
+		chain = append(chain, fmt.Sprintf("%s = %v", o.source, o.value))
+	}
+	return fmt.Sprintf("%s (overrides %s)", p.source, strings.Join(chain, ", "))
+}
+
+// addFrom adds param name from source. A param it replaces is kept in the
+// override chain, unless it is from the same source
+func (p *Parameters) addFrom(name string, value interface{}, comment string, source ParamSource) interface{} {
+	p.Init()
+	param := Parameter{name: name, value: value, comment: comment, source: source}
+	if old, ok := p.params[name]; ok {
+		if old.source == source {
+			param.overrides = old.overrides
+		} else {
+			param.overrides = append([]Parameter{old.withoutOverrides()}, old.overrides...)
+		}
+	}
+	p.params[name] = param
+	return param.value
+}
+
+func (p Parameter) withoutOverrides() Parameter {
+	p.overrides = nil
+	return p
+}
+
+// inherit adds the params of parent that p doesn't have. The params p has
+// get the parent param in their override chain
+func (p *Parameters) inherit(parent *Parameters) {
+	p.Init()
+	for name, parentParam := range parent.params {
+		param, ok := p.params[name]
+		if !ok {
+			p.params[name] = parentParam
+			continue
+		}
+		chain := append([]Parameter{}, param.overrides...)
+		chain = append(chain, parentParam.withoutOverrides())
+		param.overrides = append(chain, parentParam.overrides...)
+		p.params[name] = param
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-QA/logger"
+)
+
+func TestParamOverrideChain(t *testing.T) {
+	manager := Parameters{}
+	manager.addFrom("os", "Win64", "", ParamSource{Kind: ParamFromManager})
+	manager.addFrom("domain", "goQA", "", ParamSource{Kind: ParamFromManager})
+	suite := Parameters{}
+	suite.addFrom("os", "Linux", "", ParamSource{Kind: ParamFromSuite, Location: "/TestManager/TestSuite[@name='suite1']"})
+	suite.inherit(&manager)
+	test := Parameters{}
+	test.addFrom("os", "Mac", "", ParamSource{Kind: ParamFromTest})
+	test.addFrom("os", "MacOS", "", ParamSource{Kind: ParamFromTest})
+	test.inherit(&suite)
+
+	param, _ := test.GetParam("os")
+	overrides := param.Overrides()
+	if param.Value() != "MacOS" || len(overrides) != 2 || overrides[0].Value() != "Linux" || overrides[1].Value() != "Win64" {
+		t.Errorf("os is %v overriding %v", param.Value(), overrides)
+	}
+	want := "test (overrides suite /TestManager/TestSuite[@name='suite1'] = Linux, manager = Win64)"
+	if param.Describe() != want {
+		t.Errorf("Describe() is %q, want %q", param.Describe(), want)
+	}
+	if domain, _ := test.GetParam("domain"); domain.Source().Kind != ParamFromManager || domain.Describe() != "manager" {
+		t.Errorf("inherited param is from %s", domain.Describe())
+	}
+}
+
+func TestPlanParamSources(t *testing.T) {
+	plan := parseXMLPlan(t, `
+<TestManager name="Manager">
+  <Param name="os">Win64</Param>
+  <TestSuite name="suite1">
+    <Param name="os">Linux</Param>
+    <TestCase name="test1" class="counting">
+      <Param name="os">Mac</Param>
+    </TestCase>
+  </TestSuite>
+</TestManager>`)
+	var log, report bytes.Buffer
+	tm := newTestManager(&TextReporter{ShowParams: true}, NewJSONReporter(&report))
+	tm.AddLogger("test", logger.LogLevelAll, &log)
+	if err := tm.AddTestPlan(plan, validateRegister()); err != nil {
+		t.Fatal(err)
+	}
+	tm.RunAll()
+
+	want := "PARAM            os = Mac from test /TestManager/TestSuite[@name='suite1']/TestCase[@name='test1']" +
+		" (overrides suite /TestManager/TestSuite[@name='suite1'] = Linux, manager /TestManager = Win64)"
+	if !strings.Contains(log.String(), want) {
+		t.Errorf("text report doesn't contain %q", want)
+	}
+
+	doc, err := DecodeJSONReport(&report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var osParam JSONParam
+	for _, param := range doc.Suites[0].Tests[0].Params {
+		if param.Name == "os" {
+			osParam = param
+		}
+	}
+	if osParam.Source.Kind != ParamFromTest || len(osParam.Overrides) != 2 || osParam.Overrides[1].Source.Location != "/TestManager" {
+		t.Errorf("JSON param %+v", osParam)
+	}
+}
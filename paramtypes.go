@@ -0,0 +1,738 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats for time params, tried in order
+var paramTimeFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// paramTypes converts the value of an XMLParam by its type attribute:
+//
+//	int       int64
+//	float     float64
+//	string    string
+//	bool      bool, like "true" or "0"
+//	duration  time.Duration, like "1m30s" or seconds like "90"
+//	time      time.Time, RFC 3339 like "2016-11-21T23:26:07-05:00", "2016-11-21 23:26:07" or "2016-11-21"
+//	json      any JSON value as map[string]interface{}, []interface{}, float64, string, bool, or nil
+//
+// and list[<type>] and map[<type>] with these element types, see parseParamValue().
+// The goType attribute converts int and float values to another Go type, see
+// convertGoType()
+var paramTypes = map[string]func(value string) (interface{}, error){
+	"int":      func(value string) (interface{}, error) { return strconv.ParseInt(value, 10, 64) },
+	"float":    func(value string) (interface{}, error) { return strconv.ParseFloat(value, 64) },
+	"string":   func(value string) (interface{}, error) { return value, nil },
+	"bool":     func(value string) (interface{}, error) { return strconv.ParseBool(strings.TrimSpace(value)) },
+	"duration": func(value string) (interface{}, error) { return toDuration(strings.TrimSpace(value)) },
+	"time":     func(value string) (interface{}, error) { return toTime(strings.TrimSpace(value)) },
+	"json": func(value string) (interface{}, error) {
+		var v interface{}
+		err := json.Unmarshal([]byte(value), &v)
+		return v, err
+	},
+}
+
+// paramElemTypes are the Go types of list and map elements by type name
+var paramElemTypes = map[string]reflect.Type{
+	"int":      reflect.TypeOf(int64(0)),
+	"float":    reflect.TypeOf(float64(0)),
+	"string":   reflect.TypeOf(""),
+	"bool":     reflect.TypeOf(false),
+	"duration": reflect.TypeOf(time.Duration(0)),
+	"time":     reflect.TypeOf(time.Time{}),
+}
+
+// parseParamValue converts value to paramType. A param without type is a string.
+//
+// "list[<type>]" is a slice of <type>, like []int64 for "list[int]", from a
+// comma separated value like "1, 2, 3" or a JSON array. "list" is []string.
+// "map[<type>]" is a map[string]<type>, like map[string]float64 for "map[float]",
+// from a comma separated value like "low=1.5, high=3" or a JSON object.
+// "map" is map[string]string
+func parseParamValue(value, paramType string) (interface{}, error) {
+	if paramType == "" {
+		return value, nil
+	}
+	kind, elemType := splitParamType(paramType)
+	switch kind {
+	case "list":
+		return parseListParam(value, elemType)
+	case "map":
+		return parseMapParam(value, elemType)
+	}
+
+	convert, ok := paramTypes[paramType]
+	if !ok {
+		return nil, fmt.Errorf("unknown param type '%s'", paramType)
+	}
+	convertedVal, err := convert(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value '%s'", paramType, value)
+	}
+	return convertedVal, nil
+}
+
+// splitParamType splits "list[int]" into "list" and "int". Types other than
+// list and map are returned as kind
+func splitParamType(paramType string) (kind, elemType string) {
+	for _, kind := range []string{"list", "map"} {
+		if paramType == kind {
+			return kind, "string"
+		}
+		if strings.HasPrefix(paramType, kind+"[") && strings.HasSuffix(paramType, "]") {
+			return kind, strings.TrimSpace(paramType[len(kind)+1 : len(paramType)-1])
+		}
+	}
+	return paramType, ""
+}
+
+// decodeJSONNumbers decodes JSON text into v and keeps numbers as json.Number,
+// so large integers in lists and maps are not changed to floats
+func decodeJSONNumbers(text string, v interface{}) error {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// parseElem converts one list or map element to elemType
+func parseElem(value interface{}, elemType string) (reflect.Value, error) {
+	text, ok := value.(string)
+	if !ok {
+		// from a JSON array or object
+		text = fmt.Sprint(value)
+	}
+	elem, err := parseParamValue(text, elemType)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(elem), nil
+}
+
+func parseListParam(value, elemType string) (interface{}, error) {
+	goType, ok := paramElemTypes[elemType]
+	if !ok {
+		return nil, fmt.Errorf("unknown list element type '%s'", elemType)
+	}
+
+	var items []interface{}
+	if trimmed := strings.TrimSpace(value); strings.HasPrefix(trimmed, "[") {
+		if err := decodeJSONNumbers(trimmed, &items); err != nil {
+			return nil, fmt.Errorf("invalid list value '%s': %s", value, err.Error())
+		}
+	} else if trimmed != "" {
+		for _, item := range strings.Split(value, ",") {
+			items = append(items, strings.TrimSpace(item))
+		}
+	}
+
+	list := reflect.MakeSlice(reflect.SliceOf(goType), 0, len(items))
+	for _, item := range items {
+		elem, err := parseElem(item, elemType)
+		if err != nil {
+			return nil, fmt.Errorf("list element: %s", err.Error())
+		}
+		list = reflect.Append(list, elem)
+	}
+	return list.Interface(), nil
+}
+
+func parseMapParam(value, elemType string) (interface{}, error) {
+	goType, ok := paramElemTypes[elemType]
+	if !ok {
+		return nil, fmt.Errorf("unknown map element type '%s'", elemType)
+	}
+
+	items := map[string]interface{}{}
+	if trimmed := strings.TrimSpace(value); strings.HasPrefix(trimmed, "{") {
+		if err := decodeJSONNumbers(trimmed, &items); err != nil {
+			return nil, fmt.Errorf("invalid map value '%s': %s", value, err.Error())
+		}
+	} else if trimmed != "" {
+		for _, item := range strings.Split(value, ",") {
+			kv := strings.SplitN(item, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("invalid map item '%s', expected key=value", strings.TrimSpace(item))
+			}
+			items[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+
+	m := reflect.MakeMapWithSize(reflect.MapOf(reflect.TypeOf(""), goType), len(items))
+	for key, item := range items {
+		elem, err := parseElem(item, elemType)
+		if err != nil {
+			return nil, fmt.Errorf("map element '%s': %s", key, err.Error())
+		}
+		m.SetMapIndex(reflect.ValueOf(key), elem)
+	}
+	return m.Interface(), nil
+}
+
+// paramGoTypes are the Go types the goType attribute of an XMLParam converts
+// int and float values to, for params of Go code that aren't int64 or float64
+var paramGoTypes = map[string]reflect.Type{
+	"int":     reflect.TypeOf(int(0)),
+	"int8":    reflect.TypeOf(int8(0)),
+	"int16":   reflect.TypeOf(int16(0)),
+	"int32":   reflect.TypeOf(int32(0)),
+	"int64":   reflect.TypeOf(int64(0)),
+	"uint":    reflect.TypeOf(uint(0)),
+	"uint8":   reflect.TypeOf(uint8(0)),
+	"uint16":  reflect.TypeOf(uint16(0)),
+	"uint32":  reflect.TypeOf(uint32(0)),
+	"uint64":  reflect.TypeOf(uint64(0)),
+	"float32": reflect.TypeOf(float32(0)),
+	"float64": reflect.TypeOf(float64(0)),
+}
+
+// goParamType returns the Go type named goType, one of paramGoTypes or a
+// slice or string keyed map of them like "[]int" or "map[string]float32"
+func goParamType(goType string) (reflect.Type, error) {
+	switch {
+	case strings.HasPrefix(goType, "[]"):
+		elem, err := goParamType(goType[len("[]"):])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(elem), nil
+	case strings.HasPrefix(goType, "map[string]"):
+		elem, err := goParamType(goType[len("map[string]"):])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.MapOf(reflect.TypeOf(""), elem), nil
+	}
+	if t, ok := paramGoTypes[goType]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("unknown Go type '%s'", goType)
+}
+
+// convertGoType converts a value of parseParamValue() to goType, like an
+// int64 to int for goType "int"
+func convertGoType(value interface{}, goType string) (interface{}, error) {
+	t, err := goParamType(goType)
+	if err != nil {
+		return nil, err
+	}
+	converted, err := convertValue(value, t)
+	if err != nil {
+		return nil, fmt.Errorf("Go type %s: %s", goType, err.Error())
+	}
+	return converted.Interface(), nil
+}
+
+// ---------------------------  Conversions -------------------
+
+// toInt64 converts any integer, float without fraction, or integer string to int64
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint:
+		if uint64(v) <= math.MaxInt64 {
+			return int64(v), nil
+		}
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+	case float32:
+		if float32(int64(v)) == v {
+			return int64(v), nil
+		}
+	case float64:
+		if float64(int64(v)) == v {
+			return int64(v), nil
+		}
+	case json.Number:
+		return v.Int64()
+	case string:
+		if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("can't convert %v (%T) to an int", value, value)
+}
+
+// toInt converts like toInt64 and checks the value fits in an int
+func toInt(value interface{}) (int, error) {
+	i, err := toInt64(value)
+	if err != nil {
+		return 0, err
+	}
+	if int64(int(i)) != i {
+		return 0, fmt.Errorf("%d overflows int", i)
+	}
+	return int(i), nil
+}
+
+// toFloat64 converts any integer or float to float64
+func toFloat64(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f, nil
+		}
+		return 0, fmt.Errorf("can't convert %v (%T) to a float", value, value)
+	}
+	if i, err := toInt64(value); err == nil {
+		return float64(i), nil
+	}
+	return 0, fmt.Errorf("can't convert %v (%T) to a float", value, value)
+}
+
+// toBool converts a bool or a string like "true" or "0" to bool
+func toBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+			return b, nil
+		}
+	}
+	return false, fmt.Errorf("can't convert %v (%T) to a bool", value, value)
+}
+
+// toTime converts a time.Time or a string in one of paramTimeFormats to time.Time
+func toTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		for _, format := range paramTimeFormats {
+			if t, err := time.Parse(format, v); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("can't convert %v (%T) to a time", value, value)
+}
+
+// toList converts any slice or array to []interface{}. A string is read as a
+// JSON array or as a comma separated list of strings
+func toList(value interface{}) ([]interface{}, error) {
+	if s, ok := value.(string); ok {
+		list := []interface{}{}
+		if trimmed := strings.TrimSpace(s); strings.HasPrefix(trimmed, "[") {
+			if err := json.Unmarshal([]byte(trimmed), &list); err != nil {
+				return nil, fmt.Errorf("invalid list value '%s': %s", s, err.Error())
+			}
+		} else if trimmed != "" {
+			for _, item := range strings.Split(s, ",") {
+				list = append(list, strings.TrimSpace(item))
+			}
+		}
+		return list, nil
+	}
+	v := reflect.ValueOf(value)
+	if value == nil || (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) {
+		return nil, fmt.Errorf("can't convert %v (%T) to a list", value, value)
+	}
+	list := make([]interface{}, v.Len())
+	for i := range list {
+		list[i] = v.Index(i).Interface()
+	}
+	return list, nil
+}
+
+// toMap converts any map with string keys to map[string]interface{}
+func toMap(value interface{}) (map[string]interface{}, error) {
+	v := reflect.ValueOf(value)
+	if value == nil || v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("can't convert %v (%T) to a map", value, value)
+	}
+	m := make(map[string]interface{}, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		m[iter.Key().String()] = iter.Value().Interface()
+	}
+	return m, nil
+}
+
+// toString converts a string, fmt.Stringer, []byte, number, or bool to string
+func toString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v), nil
+	}
+	return "", fmt.Errorf("can't convert %v (%T) to a string", value, value)
+}
+
+// ---------------------------  Parameters accessors -------------------
+
+// getValue returns the value of param name, or an error if it doesn't exist
+func (p *Parameters) getValue(name string) (interface{}, error) {
+	value, ok := p.GetParamValue(name)
+	if !ok {
+		return nil, fmt.Errorf("parameter '%s' not found", name)
+	}
+	return value, nil
+}
+
+// paramError names the parameter in a conversion error
+func paramError(name string, err error) error {
+	return fmt.Errorf("parameter '%s': %s", name, err.Error())
+}
+
+// GetInt returns param name as int. Any integer type, and floats without
+// fraction, are converted. error is returned if the param doesn't exist or
+// can't be converted
+func (p *Parameters) GetInt(name string) (int, error) {
+	value, err := p.getValue(name)
+	if err != nil {
+		return 0, err
+	}
+	i, err := toInt(value)
+	if err != nil {
+		return 0, paramError(name, err)
+	}
+	return i, nil
+}
+
+// GetInt64 returns param name as int64, converted like GetInt()
+func (p *Parameters) GetInt64(name string) (int64, error) {
+	value, err := p.getValue(name)
+	if err != nil {
+		return 0, err
+	}
+	i, err := toInt64(value)
+	if err != nil {
+		return 0, paramError(name, err)
+	}
+	return i, nil
+}
+
+// GetFloat returns param name as float64. Any integer or float type is converted
+func (p *Parameters) GetFloat(name string) (float64, error) {
+	value, err := p.getValue(name)
+	if err != nil {
+		return 0, err
+	}
+	f, err := toFloat64(value)
+	if err != nil {
+		return 0, paramError(name, err)
+	}
+	return f, nil
+}
+
+// GetString returns param name if it is a string
+func (p *Parameters) GetString(name string) (string, error) {
+	value, err := p.getValue(name)
+	if err != nil {
+		return "", err
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", paramError(name, fmt.Errorf("%v (%T) is not a string", value, value))
+	}
+	return s, nil
+}
+
+// GetBool returns param name as bool. Strings like "true" or "0" are converted
+func (p *Parameters) GetBool(name string) (bool, error) {
+	value, err := p.getValue(name)
+	if err != nil {
+		return false, err
+	}
+	b, err := toBool(value)
+	if err != nil {
+		return false, paramError(name, err)
+	}
+	return b, nil
+}
+
+// GetDuration returns param name as time.Duration. Numbers are seconds and
+// strings are durations like "1m30s" or seconds
+func (p *Parameters) GetDuration(name string) (time.Duration, error) {
+	value, err := p.getValue(name)
+	if err != nil {
+		return 0, err
+	}
+	d, err := toDuration(value)
+	if err != nil {
+		return 0, paramError(name, err)
+	}
+	return d, nil
+}
+
+// GetTime returns param name as time.Time. Strings are parsed like the time param type
+func (p *Parameters) GetTime(name string) (time.Time, error) {
+	value, err := p.getValue(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := toTime(value)
+	if err != nil {
+		return time.Time{}, paramError(name, err)
+	}
+	return t, nil
+}
+
+// GetList returns param name, which can be any slice or a string like
+// "a, b, c", as []interface{}
+func (p *Parameters) GetList(name string) ([]interface{}, error) {
+	value, err := p.getValue(name)
+	if err != nil {
+		return nil, err
+	}
+	list, err := toList(value)
+	if err != nil {
+		return nil, paramError(name, err)
+	}
+	return list, nil
+}
+
+// GetMap returns param name, which can be any map with string keys, as
+// map[string]interface{}
+func (p *Parameters) GetMap(name string) (map[string]interface{}, error) {
+	value, err := p.getValue(name)
+	if err != nil {
+		return nil, err
+	}
+	m, err := toMap(value)
+	if err != nil {
+		return nil, paramError(name, err)
+	}
+	return m, nil
+}
+
+// ---------------------------  Parameters accessors with defaults -------------------
+
+// has reports if param name exists with a value other than nil
+func (p *Parameters) has(name string) bool {
+	value, ok := p.GetParamValue(name)
+	return ok && value != nil
+}
+
+// Int returns param name as int, converted like GetInt(), or def if the
+// param doesn't exist. def and an error are returned when it can't be converted
+func (p *Parameters) Int(name string, def int) (int, error) {
+	if !p.has(name) {
+		return def, nil
+	}
+	i, err := p.GetInt(name)
+	if err != nil {
+		return def, err
+	}
+	return i, nil
+}
+
+// Float returns param name as float64, converted like GetFloat(), or def if
+// the param doesn't exist
+func (p *Parameters) Float(name string, def float64) (float64, error) {
+	if !p.has(name) {
+		return def, nil
+	}
+	f, err := p.GetFloat(name)
+	if err != nil {
+		return def, err
+	}
+	return f, nil
+}
+
+// String returns param name as string, or def if the param doesn't exist.
+// Unlike GetString(), numbers, bools, and fmt.Stringer values are converted
+func (p *Parameters) String(name string, def string) (string, error) {
+	if !p.has(name) {
+		return def, nil
+	}
+	value, _ := p.GetParamValue(name)
+	s, err := toString(value)
+	if err != nil {
+		return def, paramError(name, err)
+	}
+	return s, nil
+}
+
+// Bool returns param name as bool, converted like GetBool(), or def if the
+// param doesn't exist
+func (p *Parameters) Bool(name string, def bool) (bool, error) {
+	if !p.has(name) {
+		return def, nil
+	}
+	b, err := p.GetBool(name)
+	if err != nil {
+		return def, err
+	}
+	return b, nil
+}
+
+// Duration returns param name as time.Duration, converted like GetDuration(),
+// or def if the param doesn't exist
+func (p *Parameters) Duration(name string, def time.Duration) (time.Duration, error) {
+	if !p.has(name) {
+		return def, nil
+	}
+	d, err := p.GetDuration(name)
+	if err != nil {
+		return def, err
+	}
+	return d, nil
+}
+
+// Slice returns param name as []interface{}, converted like GetList(), or
+// def if the param doesn't exist
+func (p *Parameters) Slice(name string, def []interface{}) ([]interface{}, error) {
+	if !p.has(name) {
+		return def, nil
+	}
+	list, err := p.GetList(name)
+	if err != nil {
+		return def, err
+	}
+	return list, nil
+}
+
+// ---------------------------  TestCase accessors -------------------
+
+// paramErrorHolder is implemented by tests, like TestCase, that record
+// required params that are missing or can't be converted. TestManager fails
+// Setup() of a test with any
+type paramErrorHolder interface {
+	paramErrors() []string
+}
+
+// Int returns param name of the test, see Parameters.Int()
+func (tc *TestCase) Int(name string, def int) (int, error) {
+	return tc.params.Int(name, def)
+}
+
+// Float returns param name of the test, see Parameters.Float()
+func (tc *TestCase) Float(name string, def float64) (float64, error) {
+	return tc.params.Float(name, def)
+}
+
+// String returns param name of the test, see Parameters.String()
+func (tc *TestCase) String(name string, def string) (string, error) {
+	return tc.params.String(name, def)
+}
+
+// Bool returns param name of the test, see Parameters.Bool()
+func (tc *TestCase) Bool(name string, def bool) (bool, error) {
+	return tc.params.Bool(name, def)
+}
+
+// Duration returns param name of the test, see Parameters.Duration()
+func (tc *TestCase) Duration(name string, def time.Duration) (time.Duration, error) {
+	return tc.params.Duration(name, def)
+}
+
+// Slice returns param name of the test, see Parameters.Slice()
+func (tc *TestCase) Slice(name string, def []interface{}) ([]interface{}, error) {
+	return tc.params.Slice(name, def)
+}
+
+// RequireInt returns param name as int. A missing param or one that can't
+// be converted is logged as error and fails the test with TcSetupFailed
+// after Setup() returns, so call it from Setup()
+func (tc *TestCase) RequireInt(name string) int {
+	i, err := tc.params.GetInt(name)
+	tc.requireParam(name, err)
+	return i
+}
+
+// RequireFloat returns param name as float64, see RequireInt()
+func (tc *TestCase) RequireFloat(name string) float64 {
+	f, err := tc.params.GetFloat(name)
+	tc.requireParam(name, err)
+	return f
+}
+
+// RequireString returns param name as string, converted like String(), see RequireInt()
+func (tc *TestCase) RequireString(name string) string {
+	if !tc.params.has(name) {
+		tc.requireParam(name, fmt.Errorf("parameter '%s' not found", name))
+		return ""
+	}
+	s, err := tc.params.String(name, "")
+	tc.requireParam(name, err)
+	return s
+}
+
+// RequireBool returns param name as bool, see RequireInt()
+func (tc *TestCase) RequireBool(name string) bool {
+	b, err := tc.params.GetBool(name)
+	tc.requireParam(name, err)
+	return b
+}
+
+// RequireDuration returns param name as time.Duration, see RequireInt()
+func (tc *TestCase) RequireDuration(name string) time.Duration {
+	d, err := tc.params.GetDuration(name)
+	tc.requireParam(name, err)
+	return d
+}
+
+// RequireSlice returns param name as []interface{}, see RequireInt()
+func (tc *TestCase) RequireSlice(name string) []interface{} {
+	list, err := tc.params.GetList(name)
+	tc.requireParam(name, err)
+	return list
+}
+
+// requireParam records err of required param name with the test it is
+// required by and where the value was set
+func (tc *TestCase) requireParam(name string, err error) {
+	if err == nil {
+		return
+	}
+	origin := fmt.Sprintf("test '%s'", tc.name)
+	if tc.suiteName != "" {
+		origin = fmt.Sprintf("%s of suite '%s'", origin, tc.suiteName)
+	}
+	msg := fmt.Sprintf("%s: required %s", origin, err.Error())
+	if param, ok := tc.params.GetParam(name); ok {
+		msg = fmt.Sprintf("%s (set by %s)", msg, param.Source())
+	}
+	tc.paramErrs = append(tc.paramErrs, msg)
+	tc.LogError("%s", msg)
+}
+
+func (tc *TestCase) paramErrors() []string {
+	return tc.paramErrs
+}
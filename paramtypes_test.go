@@ -0,0 +1,230 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseParamValue(t *testing.T) {
+	tests := []struct {
+		value, paramType string
+		want             interface{}
+	}{
+		{"42", "int", int64(42)},
+		{"1.5", "float", 1.5},
+		{" hi ", "string", " hi "},
+		{"raw", "", "raw"},
+		{" 0 ", "bool", false},
+		{"1m30s", "duration", 90 * time.Second},
+		{"90", "duration", 90 * time.Second},
+		{"2016-11-21", "time", time.Date(2016, 11, 21, 0, 0, 0, 0, time.UTC)},
+		{"2016-11-21 23:26:07", "time", time.Date(2016, 11, 21, 23, 26, 7, 0, time.UTC)},
+		{`{"a": [1, true]}`, "json", map[string]interface{}{"a": []interface{}{1.0, true}}},
+		{"a, b ,c", "list", []string{"a", "b", "c"}},
+		{"", "list[int]", []int64{}},
+		{"1, 2, 3", "list[int]", []int64{1, 2, 3}},
+		{"[9007199254740993, 2]", "list[int]", []int64{9007199254740993, 2}},
+		{`["1s", "2m"]`, "list[duration]", []time.Duration{time.Second, 2 * time.Minute}},
+		{"low=1.5, high = 3", "map[float]", map[string]float64{"low": 1.5, "high": 3}},
+		{`{"on": true}`, "map[bool]", map[string]bool{"on": true}},
+		{"k=v", "map", map[string]string{"k": "v"}},
+	}
+	for _, test := range tests {
+		value, err := parseParamValue(test.value, test.paramType)
+		if err != nil {
+			t.Errorf("%s %q: %s", test.paramType, test.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, test.want) {
+			t.Errorf("%s %q is %#v, want %#v", test.paramType, test.value, value, test.want)
+		}
+	}
+
+	for _, test := range []struct{ value, paramType, err string }{
+		{"ten", "int", "invalid int value 'ten'"},
+		{"1", "bogus", "unknown param type 'bogus'"},
+		{"1, x", "list[int]", "list element: invalid int value 'x'"},
+		{"1", "list[json]", "unknown list element type 'json'"},
+		{"novalue", "map[int]", "invalid map item 'novalue', expected key=value"},
+		{"[1,", "list[int]", "invalid list value '[1,'"},
+	} {
+		_, err := parseParamValue(test.value, test.paramType)
+		if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("%s %q: want error %q, got %v", test.paramType, test.value, test.err, err)
+		}
+	}
+}
+
+func TestParametersGetters(t *testing.T) {
+	p := Parameters{}
+	p.AddParam("int", int32(7), "")
+	p.AddParam("whole", 3.0, "")
+	p.AddParam("fraction", 3.5, "")
+	p.AddParam("number", json.Number("12"), "")
+	p.AddParam("big", uint64(1<<63), "")
+	p.AddParam("text", "1.25", "")
+	p.AddParam("flag", "yes", "")
+	p.AddParam("on", "true", "")
+	p.AddParam("seconds", 2, "")
+	p.AddParam("when", "2016-11-21T23:26:07Z", "")
+	p.AddParam("list", []int64{1, 2}, "")
+	p.AddParam("map", map[string]float64{"x": 1}, "")
+
+	if i, err := p.GetInt("int"); i != 7 || err != nil {
+		t.Errorf("GetInt of int32 is %d, %v", i, err)
+	}
+	if i, err := p.GetInt("whole"); i != 3 || err != nil {
+		t.Errorf("GetInt of a float without fraction is %d, %v", i, err)
+	}
+	if i, err := p.GetInt64("number"); i != 12 || err != nil {
+		t.Errorf("GetInt64 of json.Number is %d, %v", i, err)
+	}
+	if f, err := p.GetFloat("text"); f != 1.25 || err != nil {
+		t.Errorf("GetFloat of a string is %g, %v", f, err)
+	}
+	if b, err := p.GetBool("on"); !b || err != nil {
+		t.Errorf("GetBool of \"true\" is %t, %v", b, err)
+	}
+	if d, err := p.GetDuration("seconds"); d != 2*time.Second || err != nil {
+		t.Errorf("GetDuration of an int is %s, %v", d, err)
+	}
+	if tm, err := p.GetTime("when"); !tm.Equal(time.Date(2016, 11, 21, 23, 26, 7, 0, time.UTC)) || err != nil {
+		t.Errorf("GetTime is %s, %v", tm, err)
+	}
+	if l, err := p.GetList("list"); !reflect.DeepEqual(l, []interface{}{int64(1), int64(2)}) || err != nil {
+		t.Errorf("GetList of a slice is %v, %v", l, err)
+	}
+	if m, err := p.GetMap("map"); !reflect.DeepEqual(m, map[string]interface{}{"x": 1.0}) || err != nil {
+		t.Errorf("GetMap is %v, %v", m, err)
+	}
+
+	errs := map[string]error{}
+	_, errs["fraction"] = p.GetInt("fraction")
+	_, errs["big"] = p.GetInt64("big")
+	_, errs["flag"] = p.GetBool("flag")
+	_, errs["int"] = p.GetString("int")
+	_, errs["text"] = p.GetMap("text")
+	_, errs["missing"] = p.GetFloat("missing")
+	for name, err := range errs {
+		if err == nil {
+			t.Errorf("no error for param %s", name)
+		}
+	}
+	if err := errs["missing"]; err != nil && err.Error() != "parameter 'missing' not found" {
+		t.Errorf("error for a missing param is %q", err)
+	}
+	if err := errs["fraction"]; err != nil && err.Error() != "parameter 'fraction': can't convert 3.5 (float64) to an int" {
+		t.Errorf("conversion error is %q", err)
+	}
+}
+
+func TestParametersDefaults(t *testing.T) {
+	p := Parameters{}
+	p.AddParam("count", "5", "")
+	p.AddParam("bad", "x", "")
+	p.AddParam("nothing", nil, "")
+	p.AddParam("port", 8080, "")
+
+	if i, err := p.Int("count", 1); i != 5 || err != nil {
+		t.Errorf("Int is %d, %v", i, err)
+	}
+	if i, err := p.Int("missing", 1); i != 1 || err != nil {
+		t.Errorf("Int of a missing param is %d, %v", i, err)
+	}
+	if i, err := p.Int("nothing", 1); i != 1 || err != nil {
+		t.Errorf("Int of a nil param is %d, %v", i, err)
+	}
+	if i, err := p.Int("bad", 1); i != 1 || err == nil {
+		t.Errorf("Int of an invalid param is %d, %v", i, err)
+	}
+	if s, err := p.String("port", ""); s != "8080" || err != nil {
+		t.Errorf("String of an int is %q, %v", s, err)
+	}
+	if d, err := p.Duration("missing", time.Minute); d != time.Minute || err != nil {
+		t.Errorf("Duration of a missing param is %s, %v", d, err)
+	}
+	if l, err := p.Slice("count", nil); !reflect.DeepEqual(l, []interface{}{"5"}) || err != nil {
+		t.Errorf("Slice is %v, %v", l, err)
+	}
+}
+
+// requiringTest requires its params in Setup()
+type requiringTest struct {
+	TestCase
+	count int
+	name  string
+}
+
+func (t *requiringTest) Setup() (int, error) {
+	t.count = t.RequireInt("count")
+	t.name = t.RequireString("name")
+	return TcPassed, nil
+}
+
+func (t *requiringTest) Run() (int, error) {
+	return t.ReturnFromRun()
+}
+
+func TestRequiredParams(t *testing.T) {
+	tm := newTestManager()
+	suite := NewSuite("suite1", tm, Parameters{})
+	params := Parameters{}
+	params.AddParam("count", "many", "")
+	test := &requiringTest{}
+	suite.AddTest(test, "test1", params)
+	tm.AddSuite(suite)
+	tm.report.suiteStarted("suite1", "")
+
+	result := runTest(tm, "suite1", test)
+	if result.Status != TcSetupFailed {
+		t.Fatalf("test with invalid params is %s", TcStatusName(result.Status))
+	}
+	for _, want := range []string{
+		"test 'test1' of suite 'suite1': required parameter 'count': can't convert many (string) to an int (set by",
+		"required parameter 'name' not found",
+	} {
+		if !strings.Contains(result.StatusMessage, want) {
+			t.Errorf("status message %q doesn't contain %q", result.StatusMessage, want)
+		}
+	}
+
+	test.AddParam("count", 3, "")
+	test.AddParam("name", "x", "")
+	test.resetAttempt()
+	if result := runTest(tm, "suite1", test); result.Status != TcPassed || test.count != 3 {
+		t.Errorf("test with valid params is %s, count %d", TcStatusName(result.Status), test.count)
+	}
+}
+
+func TestInvalidFailureThreshold(t *testing.T) {
+	tm := newTestManager()
+	tm.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, Statuses: []int{TcSetupFailed}})
+	params := Parameters{}
+	params.AddParam("failureThreshold", "half", "")
+	test := &countingTest{}
+	suite := NewSuite("suite1", tm, Parameters{})
+	suite.AddTest(test, "test1", params)
+	tm.AddSuite(suite)
+	tm.report.suiteStarted("suite1", "")
+
+	result := runTest(tm, "suite1", test)
+	attempts := append(result.PreviousAttempts(), result)
+	if len(attempts) != 2 {
+		t.Fatalf("%d attempts, want 2", len(attempts))
+	}
+	for i, attempt := range attempts {
+		if attempt.Status != TcSetupFailed || !strings.Contains(attempt.StatusMessage, "failureThreshold") {
+			t.Errorf("attempt %d is %s: %s", i+1, TcStatusName(attempt.Status), attempt.StatusMessage)
+		}
+	}
+	if test.runs != 0 {
+		t.Errorf("test with an invalid failureThreshold ran")
+	}
+}
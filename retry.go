@@ -0,0 +1,141 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Test parameters that override the RetryPolicy for a test
+const (
+	RetryAttemptsParam = "retryAttempts" // MaxAttempts as int
+	RetryDelayParam    = "retryDelay"    // Delay in seconds or as duration string like "500ms"
+	RetryBackoffParam  = "retryBackoff"  // Backoff as float
+	RetryOnParam       = "retryOn"       // Statuses as comma separated status names like "Failed,Error"
+)
+
+// DefaultRetryStatuses are retried when RetryPolicy.Statuses is empty
+var DefaultRetryStatuses = []int{TcFailed, TcError, TcSetupError}
+
+// RetryPolicy controls how many times a test is run when it doesn't pass.
+// A test that passes after a retry is reported as TcFlaky
+type RetryPolicy struct {
+	MaxAttempts int           // times the test is run at most. 0 or 1 doesn't retry
+	Delay       time.Duration // wait before the first retry
+	Backoff     float64       // Delay is multiplied by Backoff after each retry. 0 keeps the same Delay
+	Statuses    []int         // Tc* statuses that are retried. Empty uses DefaultRetryStatuses
+}
+
+// retries returns true if a test with status should be run again
+func (p *RetryPolicy) retries(status int) bool {
+	statuses := p.Statuses
+	if len(statuses) == 0 {
+		statuses = DefaultRetryStatuses
+	}
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns the time to wait before the retry after attempt
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	delay := float64(p.Delay)
+	if p.Backoff > 0 {
+		for i := 1; i < attempt; i++ {
+			delay *= p.Backoff
+		}
+	}
+	return time.Duration(delay)
+}
+
+// applyParams overrides the policy with the retry parameters in params
+func (p *RetryPolicy) applyParams(params *Parameters) error {
+	if value, ok := params.GetParamValue(RetryAttemptsParam); ok {
+		attempts, err := toInt(value)
+		if err != nil {
+			return fmt.Errorf("%s: %s", RetryAttemptsParam, err.Error())
+		}
+		p.MaxAttempts = attempts
+	}
+	if value, ok := params.GetParamValue(RetryDelayParam); ok {
+		delay, err := toDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: %s", RetryDelayParam, err.Error())
+		}
+		p.Delay = delay
+	}
+	if value, ok := params.GetParamValue(RetryBackoffParam); ok {
+		backoff, err := toFloat64(value)
+		if err != nil {
+			return fmt.Errorf("%s: %s", RetryBackoffParam, err.Error())
+		}
+		p.Backoff = backoff
+	}
+	if value, ok := params.GetParamValue(RetryOnParam); ok {
+		names, isString := value.(string)
+		if !isString {
+			return fmt.Errorf("%s must be a string, not %T", RetryOnParam, value)
+		}
+		statuses := []int{}
+		for _, name := range strings.Split(names, ",") {
+			status, found := TcStatusFromName(strings.TrimSpace(name))
+			if !found {
+				return fmt.Errorf("%s has unknown status '%s'", RetryOnParam, name)
+			}
+			statuses = append(statuses, status)
+		}
+		p.Statuses = statuses
+	}
+	return nil
+}
+
+// retrySuite is implemented by suites, like DefaultSuite, that override
+// the manager retry policy for their tests
+type retrySuite interface {
+	RetryPolicy() RetryPolicy
+}
+
+// SetRetryPolicy sets the default retry policy for all tests.
+// Suites and test parameters can override it
+func (tm *TestManager) SetRetryPolicy(policy RetryPolicy) {
+	tm.retryPolicy = policy
+}
+
+// RetryPolicy returns the default retry policy set by SetRetryPolicy()
+func (tm *TestManager) RetryPolicy() RetryPolicy {
+	return tm.retryPolicy
+}
+
+// getRetryPolicy returns the retry policy for tc. The RetryPolicy() of the
+// suite is used when MaxAttempts is set, otherwise the manager policy.
+// Retry test parameters then override single fields
+func (tm *TestManager) getRetryPolicy(suiteName string, tc Tester) RetryPolicy {
+	policy := tm.retryPolicy
+	if suite, ok := tm.GetSuite(suiteName).(retrySuite); ok && suite.RetryPolicy().MaxAttempts > 0 {
+		policy = suite.RetryPolicy()
+	}
+	if p, ok := tc.(paramHolder); ok {
+		if err := policy.applyParams(p.GetParams()); err != nil {
+			tm.log.LogWarning("Test '%s' has invalid retry parameter::error=%s", tc.Name(), err.Error())
+		}
+	}
+	return policy
+}
+
+// SetRetryPolicy overrides the manager retry policy for tests in the suite.
+// A policy with MaxAttempts 0 uses the manager policy
+func (s *DefaultSuite) SetRetryPolicy(policy RetryPolicy) {
+	s.retryPolicy = policy
+}
+
+// RetryPolicy returns the retry policy set by SetRetryPolicy()
+func (s *DefaultSuite) RetryPolicy() RetryPolicy {
+	return s.retryPolicy
+}
@@ -0,0 +1,183 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-QA/logger"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, Delay: 100 * time.Millisecond, Backoff: 2}
+	if policy.delay(1) != 100*time.Millisecond || policy.delay(3) != 400*time.Millisecond {
+		t.Errorf("delays %s and %s, want 100ms and 400ms", policy.delay(1), policy.delay(3))
+	}
+	if !policy.retries(TcFailed) || policy.retries(TcTimedOut) {
+		t.Errorf("default statuses are not retried as DefaultRetryStatuses")
+	}
+
+	params := Parameters{}
+	params.AddParam(RetryAttemptsParam, 5, "")
+	params.AddParam(RetryDelayParam, "1s", "")
+	params.AddParam(RetryOnParam, "TimedOut, failed", "")
+	if err := policy.applyParams(&params); err != nil {
+		t.Fatal(err)
+	}
+	want := RetryPolicy{MaxAttempts: 5, Delay: time.Second, Backoff: 2, Statuses: []int{TcTimedOut, TcFailed}}
+	if !reflect.DeepEqual(policy, want) {
+		t.Errorf("policy %+v, want %+v", policy, want)
+	}
+
+	params.AddParam(RetryOnParam, "Failed,Bogus", "")
+	if err := policy.applyParams(&params); err == nil {
+		t.Errorf("no error for an unknown status")
+	}
+}
+
+// flakyTest fails its check point until it has run more than failures times
+type flakyTest struct {
+	TestCase
+	failures int
+	runs     int
+}
+
+func (t *flakyTest) Run() (int, error) {
+	t.runs++
+	t.Verify(t.runs > t.failures, "device answered", "no answer on run %d", t.runs)
+	return t.ReturnFromRun()
+}
+
+// criticalTest logs an error in a critical section
+type criticalTest struct {
+	TestCase
+}
+
+func (t *criticalTest) Run() (int, error) {
+	t.Critical.Start()
+	t.LogError("device lost")
+	t.Critical.End()
+	return t.ReturnFromRun()
+}
+
+// waitingTest runs until its context is done
+type waitingTest struct {
+	TestCase
+	runs int32
+}
+
+func (t *waitingTest) RunContext(ctx context.Context) (int, error) {
+	atomic.AddInt32(&t.runs, 1)
+	<-ctx.Done()
+	return t.ReturnFromRun()
+}
+
+func TestRetriedTests(t *testing.T) {
+	tm := NewManager(ioutil.Discard, nil, SuiteSerial, TcSerial)
+	tm.SetRetryPolicy(RetryPolicy{MaxAttempts: 3})
+	flaky := &flakyTest{failures: 1}
+	failing := &flakyTest{failures: 100}
+	suite := NewSuite("suite1", &tm, Parameters{})
+	suite.AddTest(flaky, "flaky", Parameters{})
+	suite.AddTest(failing, "failing", Parameters{})
+	suite.AddTest(&criticalTest{}, "critical", Parameters{})
+	tm.AddSuite(suite)
+	tm.report.suiteStarted("suite1", "")
+
+	result := runTest(&tm, "suite1", flaky)
+	if result.Status != TcFlaky || result.Attempts() != 2 || flaky.runs != 2 {
+		t.Errorf("flaky test is %s after %d attempts and %d runs", TcStatusName(result.Status), result.Attempts(), flaky.runs)
+	}
+	if prev := result.PreviousAttempts(); len(prev) != 1 || prev[0].Status != TcFailed {
+		t.Errorf("previous attempts %+v", prev)
+	}
+	if strings.Contains(result.Output(), "no answer") {
+		t.Errorf("output of the first attempt is kept in the retry: %q", result.Output())
+	}
+	if result := runTest(&tm, "suite1", failing); result.Status != TcFailed || result.Attempts() != 3 {
+		t.Errorf("failing test is %s after %d attempts", TcStatusName(result.Status), result.Attempts())
+	}
+	// only DefaultRetryStatuses are retried
+	if result := runTest(&tm, "suite1", suite.GetTestCase("critical")); result.Status != TcCriticalError || result.Attempts() != 1 {
+		t.Errorf("critical test is %s after %d attempts", TcStatusName(result.Status), result.Attempts())
+	}
+}
+
+func TestTimedOutRetryWaitsForAttempt(t *testing.T) {
+	tm := NewManager(ioutil.Discard, nil, SuiteSerial, TcSerial)
+	tm.SetTestTimeout(20 * time.Millisecond)
+	tm.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, Statuses: []int{TcTimedOut}})
+
+	// the context of the attempt is cancelled, so the test returns and is retried
+	test := &waitingTest{}
+	test.Init("cooperative", &tm, Parameters{})
+	result := runTest(&tm, "", test)
+	if result.Attempts() != 2 || atomic.LoadInt32(&test.runs) != 2 {
+		t.Errorf("cooperative test ran %d times with %d attempts, want 2", test.runs, result.Attempts())
+	}
+
+	// a test that ignores its context is still running, so it isn't retried
+	stuck := &sleepingTest{sleep: 200 * time.Millisecond, done: make(chan bool)}
+	stuck.Init("stuck", &tm, Parameters{})
+	result = runTest(&tm, "", stuck)
+	if result.Attempts() != 1 || !strings.Contains(result.StatusMessage, "not retried") {
+		t.Errorf("stuck test has %d attempts: %s", result.Attempts(), result.StatusMessage)
+	}
+	<-stuck.done
+}
+
+func TestRetryAttemptsInReports(t *testing.T) {
+	var junit, html, log bytes.Buffer
+	tm := NewManager(ioutil.Discard, NewJUnitReporter(&junit), SuiteSerial, TcSerial)
+	tm.AddReporter(NewHTMLReporter(&html))
+	tm.AddReporter(&TextReporter{})
+	tm.AddLogger("test", logger.LogLevelAll, &log)
+	tm.SetRetryPolicy(RetryPolicy{MaxAttempts: 3})
+	suite := NewSuite("suite1", &tm, Parameters{})
+	suite.AddTest(&flakyTest{failures: 1}, "flaky", Parameters{})
+	suite.AddTest(&flakyTest{failures: 100}, "failing", Parameters{})
+	suite.AddTest(&flakyTest{}, "pass", Parameters{})
+	tm.AddSuite(suite)
+	tm.RunAll()
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal(junit.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	attempts := map[string]string{}
+	for _, tc := range doc.Suites[0].TestCases {
+		if tc.Properties != nil {
+			for _, p := range tc.Properties.Properties {
+				if p.Name == "attempts" {
+					attempts[tc.Name] = p.Value
+				}
+			}
+		}
+	}
+	if want := map[string]string{"flaky": "2", "failing": "3"}; !reflect.DeepEqual(attempts, want) {
+		t.Errorf("JUnit attempts %v, want %v", attempts, want)
+	}
+
+	text := log.String()
+	for _, want := range []string{"TEST FLAKY", "(2 attempts)", "(3 attempts)", "Timed out   0, Flaky   1"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text report doesn't contain %q", want)
+		}
+	}
+	if strings.Contains(text, "(1 attempts)") {
+		t.Errorf("text report shows attempts of a test that wasn't retried")
+	}
+	if !strings.Contains(html.String(), "<th>Flaky</th>") {
+		t.Errorf("HTML summary has no flaky column")
+	}
+}
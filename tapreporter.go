@@ -0,0 +1,143 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/go-QA/logger"
+)
+
+// TAPReporter is a ReportWriter that writes results in TAP version 13
+// (Test Anything Protocol) as each test finishes. The plan line "1..N"
+// is written at the end of the run by PerformManagerStatistics().
+//
+// Each test gets an "ok" or "not ok" line with a YAML diagnostic block:
+//
+//	ok 1 - suite1/test1
+//	  ---
+//	  suite: "suite1"
+//	  status: "Passed"
+//	  message: "Test complete"
+//	  duration_ms: 102
+//	  ...
+type TAPReporter struct {
+	mutex    sync.Mutex
+	name     string
+	log      *logger.GoQALog
+	parent   Manager
+	output   reportOutput
+	writer   io.Writer
+	closer   func() error
+	openErr  error // the output couldn't be opened, nothing more is written
+	count    int
+	errCount int
+}
+
+// NewTAPReporter returns a TAPReporter that writes TAP to w
+func NewTAPReporter(w io.Writer) *TAPReporter {
+	return &TAPReporter{output: reportOutput{writer: w}}
+}
+
+// NewTAPReporterFile returns a TAPReporter that creates fileName and
+// writes TAP to it. The file is overwritten if it already exists
+func NewTAPReporterFile(fileName string) *TAPReporter {
+	return &TAPReporter{output: reportOutput{fileName: fileName}}
+}
+
+func (t *TAPReporter) Name() string {
+	return t.name
+}
+
+func (t *TAPReporter) Init(parent Manager) {
+	t.name = "TAPReporter"
+	t.log = parent.GetLogger()
+	t.parent = parent
+}
+
+// TestStarted is part of TestListener. TAP has no line for started tests
+func (t *TAPReporter) TestStarted(suiteName, testName string) {
+}
+
+// TestFinished is part of TestListener and writes the TAP line for the test
+func (t *TAPReporter) TestFinished(suiteName string, result testResult) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.count++
+	line := "ok"
+	directive := ""
+	switch result.Status {
+	case TcPassed, TcFlaky:
+	case TcSkipped:
+		directive = " # SKIP " + result.StatusMessage
+	default:
+		line = "not ok"
+	}
+
+	t.printf("%s %d - %s/%s%s\n", line, t.count, suiteName, result.Name(), directive)
+	t.printf("  ---\n")
+	t.printf("  suite: %s\n", strconv.Quote(suiteName))
+	t.printf("  status: %s\n", strconv.Quote(TcStatusName(result.Status)))
+	t.printf("  message: %s\n", strconv.Quote(result.StatusMessage))
+	t.printf("  duration_ms: %d\n", int64(result.Runtime()*1000))
+	if result.Attempts() > 1 {
+		t.printf("  attempts: %d\n", result.Attempts())
+	}
+	t.printf("  ...\n")
+}
+
+// PerformManagerStatistics writes the TAP plan line and closes the output
+func (t *TAPReporter) PerformManagerStatistics(report *ManagerResult, name, msg string, complete chan int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	defer func() { complete <- 1 }()
+
+	t.printf("1..%d\n", t.count)
+	if t.closer != nil {
+		if err := t.closer(); err != nil {
+			t.logError(err)
+		}
+	}
+	t.writer = nil
+	t.closer = nil
+	t.openErr = nil
+	t.count = 0
+	t.errCount = 0
+}
+
+// printf writes to the output and opens it with the TAP header first
+// if needed. Only the first error is logged so a broken output does not
+// flood the log. When the output can't be opened it isn't tried again
+// until the next run, so a file isn't created over and over
+func (t *TAPReporter) printf(format string, args ...interface{}) {
+	if t.openErr != nil {
+		return
+	}
+	if t.writer == nil {
+		w, closer, err := t.output.open()
+		if err != nil {
+			t.openErr = err
+			t.logError(err)
+			return
+		}
+		t.writer = w
+		t.closer = closer
+		t.printf("TAP version 13\n")
+	}
+	if _, err := fmt.Fprintf(t.writer, format, args...); err != nil {
+		t.logError(err)
+	}
+}
+
+func (t *TAPReporter) logError(err error) {
+	if t.errCount == 0 {
+		t.log.LogError("TAPReporter unable to write report::error=%s", err.Error())
+	}
+	t.errCount++
+}
@@ -0,0 +1,85 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-QA/logger"
+)
+
+func TestTAPReporter(t *testing.T) {
+	var buf bytes.Buffer
+	tm := NewManager(ioutil.Discard, NewTAPReporter(&buf), SuiteSerial, TcSerial)
+	suite := NewSuite("suite1", &tm, Parameters{})
+	suite.AddTest(&checkTest{checks: []bool{true}}, "pass", Parameters{})
+	suite.AddTest(&checkTest{checks: []bool{false}}, "fail", Parameters{})
+	tm.AddSuite(suite)
+	tm.RunAll()
+
+	lines := strings.Split(buf.String(), "\n")
+	if lines[0] != "TAP version 13" {
+		t.Errorf("first line is %q", lines[0])
+	}
+	for _, want := range []string{
+		"ok 1 - suite1/pass",
+		"not ok 2 - suite1/fail",
+		`  status: "Failed"`,
+		"1..2",
+	} {
+		found := false
+		for _, line := range lines {
+			if strings.HasPrefix(line, want) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no line %q in:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestTAPReporterOpenError(t *testing.T) {
+	tm := NewManager(ioutil.Discard, &TextReporter{}, SuiteSerial, TcSerial)
+	tap := NewTAPReporterFile(filepath.Join(t.TempDir(), "missing", "report.tap"))
+	tap.Init(&tm)
+	result := testResult{}
+	result.Init("test1")
+	for i := 0; i < 3; i++ {
+		tap.TestFinished("suite1", result)
+	}
+	if tap.openErr == nil || tap.errCount != 1 {
+		t.Errorf("output was opened again after it failed, errors %d", tap.errCount)
+	}
+
+	complete := make(chan int, 1)
+	tap.PerformManagerStatistics(&tm.report, "Test Manager", "", complete)
+	<-complete
+	if tap.openErr != nil {
+		t.Errorf("open error isn't cleared for the next run")
+	}
+}
+
+func TestTAPReporterCloseError(t *testing.T) {
+	var log bytes.Buffer
+	tm := NewManager(ioutil.Discard, &TextReporter{}, SuiteSerial, TcSerial)
+	tm.AddLogger("test", logger.LogLevelAll, &log)
+	tap := NewTAPReporter(&bytes.Buffer{})
+	tap.Init(&tm)
+	tap.writer = &bytes.Buffer{}
+	tap.closer = func() error { return errors.New("disk full") }
+
+	complete := make(chan int, 1)
+	tap.PerformManagerStatistics(&tm.report, "Test Manager", "", complete)
+	<-complete
+	if !strings.Contains(log.String(), "disk full") {
+		t.Errorf("close error isn't logged:\n%s", log.String())
+	}
+}
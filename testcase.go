@@ -5,12 +5,17 @@
 package goQA
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 	//"error"
 	//"os"
 	//"io"
-	//"time"
 	"github.com/go-QA/logger"
 )
 
@@ -59,8 +64,9 @@ func (err *TestError) Create(params *Parameters, mes string) {
 	err.message = fmt.Sprint("%sSTACK::%s\n", err.message, err.stack[:])
 	if err.params.Count() > 0 {
 		mes = fmt.Sprintf("%s\nPARMETERS::\n", err.message)
-		for name, param := range err.params.params {
-			mes = fmt.Sprintf("\t%s name: %s, value: %v, comment: %s\n", mes, name, param.value, param.comment)
+		for _, name := range err.params.Names() {
+			param := err.params.params[name]
+			mes = fmt.Sprintf("%s\tname: %s, value: %v, comment: %s, source: %s\n", mes, name, param.value, param.comment, param.Describe())
 		}
 	}
 	err.message = mes
@@ -105,15 +111,29 @@ func (s *Section) Triggered() bool {
 }
 
 type Parameter struct {
-	name    string
-	value   interface{}
-	comment string
+	name      string
+	value     interface{}
+	comment   string
+	source    ParamSource
+	overrides []Parameter // params with the same name this one replaced, nearest first
 }
 
 func (p *Parameter) String() string {
 	return fmt.Sprintf("%v", p.value)
 }
 
+func (p *Parameter) Name() string {
+	return p.name
+}
+
+func (p *Parameter) Value() interface{} {
+	return p.value
+}
+
+func (p *Parameter) Comment() string {
+	return p.comment
+}
+
 type Parameters struct {
 	params map[string]Parameter
 }
@@ -134,7 +154,9 @@ func (p *Parameters) Count() int {
 func (p *Parameters) updateValue(name string, value interface{}) interface{} {
 	p.Init()
 	if _, ok := p.params[name]; ok {
-		p.params[name] = Parameter{name, value, p.params[name].comment}
+		param := p.params[name]
+		param.value = value
+		p.params[name] = param
 	}
 	return p.params[name].value
 }
@@ -142,7 +164,7 @@ func (p *Parameters) updateValue(name string, value interface{}) interface{} {
 func (p *Parameters) InitParam(name string, value interface{}) interface{} {
 	p.Init()
 	if _, present := p.params[name]; !present {
-		p.params[name] = Parameter{name, value, ""}
+		p.params[name] = Parameter{name: name, value: value, source: ParamSource{Kind: ParamFromDefault}}
 	} else {
 		if p.params[name].value == nil && value != nil {
 			p.updateValue(name, value)
@@ -154,9 +176,7 @@ func (p *Parameters) InitParam(name string, value interface{}) interface{} {
 // AddParam to the list of parameters. Will overwrite if already exists
 // returns the value that is added as interface{}
 func (p *Parameters) AddParam(name string, value interface{}, comment string) interface{} {
-	p.Init()
-	p.params[name] = Parameter{name, value, comment}
-	return p.params[name].value
+	return p.addFrom(name, value, comment, ParamSource{Kind: ParamFromAPI})
 }
 
 // GetParam will return a Param object based on the param name passed in
@@ -185,6 +205,53 @@ func (p *Parameters) GetParamComment(name string) (interface{}, bool) {
 	return param.comment, true
 }
 
+// copy returns a Parameters object with its own copy of the parameter list
+func (p *Parameters) copy() Parameters {
+	params := Parameters{}
+	params.Init()
+	for name, param := range p.params {
+		params.params[name] = param
+	}
+	return params
+}
+
+// Names returns the parameter names in sorted order
+func (p *Parameters) Names() []string {
+	names := make([]string, 0, p.Count())
+	for name := range p.params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// toDuration converts a parameter value to time.Duration. Numbers are seconds
+// and strings are parsed with time.ParseDuration() or as seconds
+func toDuration(value interface{}) (time.Duration, error) {
+	switch v := value.(type) {
+	case time.Duration:
+		return v, nil
+	case int:
+		return time.Duration(v) * time.Second, nil
+	case int64:
+		return time.Duration(v) * time.Second, nil
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	case string:
+		if d, err := time.ParseDuration(v); err == nil {
+			return d, nil
+		}
+		if sec, err := strconv.ParseFloat(v, 64); err == nil {
+			return time.Duration(sec * float64(time.Second)), nil
+		}
+	default:
+		if sec, err := toFloat64(value); err == nil {
+			return time.Duration(sec * float64(time.Second)), nil
+		}
+	}
+	return 0, fmt.Errorf("can't convert %v (%T) to a duration", value, value)
+}
+
 // the CreateParameters returns new empty Parameters object
 func NewParameters() Parameters {
 	return Parameters{}
@@ -198,10 +265,74 @@ type Tester interface {
 	Teardown() (int, error)
 }
 
+// ContextTester is a Tester with methods that get a context.Context. The
+// context is cancelled when the test times out or the run is cancelled.
+// TestManager calls the context methods instead of Setup(), Run() and Teardown()
+type ContextTester interface {
+	Tester
+	SetupContext(ctx context.Context) (int, error)
+	RunContext(ctx context.Context) (int, error)
+	TeardownContext(ctx context.Context) (int, error)
+}
+
+type contextSetupper interface {
+	SetupContext(ctx context.Context) (int, error)
+}
+
+type contextRunner interface {
+	RunContext(ctx context.Context) (int, error)
+}
+
+type contextTeardowner interface {
+	TeardownContext(ctx context.Context) (int, error)
+}
+
+// NewContextTester returns test as a ContextTester. When test doesn't have
+// all the context methods, the missing ones call Setup(), Run() or Teardown().
+// So a test that embeds TestCase can define only RunContext(), and older
+// tests work unchanged and can get the context from TestCase.Context()
+func NewContextTester(test Tester) ContextTester {
+	if ct, ok := test.(ContextTester); ok {
+		return ct
+	}
+	return &contextTester{test}
+}
+
+// contextTester adapts a Tester to ContextTester
+type contextTester struct {
+	Tester
+}
+
+func (t *contextTester) SetupContext(ctx context.Context) (int, error) {
+	if c, ok := t.Tester.(contextSetupper); ok {
+		return c.SetupContext(ctx)
+	}
+	return t.Tester.Setup()
+}
+
+func (t *contextTester) RunContext(ctx context.Context) (int, error) {
+	if c, ok := t.Tester.(contextRunner); ok {
+		return c.RunContext(ctx)
+	}
+	return t.Tester.Run()
+}
+
+func (t *contextTester) TeardownContext(ctx context.Context) (int, error) {
+	if c, ok := t.Tester.(contextTeardowner); ok {
+		return c.TeardownContext(ctx)
+	}
+	return t.Tester.Teardown()
+}
+
 type TestCase struct {
-	name   string
-	parent Manager
-	log    *logger.GoQALog
+	ctx       context.Context
+	name      string
+	suiteName string // suite the test is running in
+	parent    Manager
+	log       *logger.GoQALog
+	// mutex guards the ctx, params, counts, Critical and output, a test that
+	// timed out is left running while the manager reads them
+	mutex sync.Mutex
 	//logChannel chan []byte
 	params                                 Parameters
 	failureThreshold                       int // percentage of check points that can fail for test case to passed
@@ -210,6 +341,8 @@ type TestCase struct {
 	//result TODO Define itss
 	startTime float64
 	endTime   float64
+	output    bytes.Buffer // log output captured for reports
+	paramErrs []string     // required params that are missing or invalid
 }
 
 func (tc *TestCase) Name() string {
@@ -221,8 +354,11 @@ func (tc *TestCase) Init(name string, parent Manager, params Parameters) Tester
 	tc.parent = parent
 	tc.log = parent.GetLogger()
 	tc.params = params
-	tc.failureThreshold = tc.InitParam("failureThreshold", 0).(int)
 	tc.Critical = Section{}
+	tc.output.Reset()
+	tc.paramErrs = nil
+	tc.InitParam("failureThreshold", 0)
+	tc.failureThreshold, _ = tc.Int("failureThreshold", 0)
 	return tc
 }
 
@@ -265,44 +401,138 @@ func (tc *TestCase) GetLogger() *logger.GoQALog {
 }
 
 func (tc *TestCase) LogError(errMsg string, args ...interface{}) {
+	tc.mutex.Lock()
 	tc.Critical.Trigger()
 	tc.failedCount++
+	tc.mutex.Unlock()
 	tc.log.LogError(errMsg, args...)
+	tc.capture("ERROR::", errMsg, args...)
+	tc.checkpoint(ResultError, errMsg, args...)
 }
 
 func (tc *TestCase) LogFail(failMsg string, args ...interface{}) {
+	tc.mutex.Lock()
 	tc.Critical.Trigger()
 	tc.failedCount++
+	tc.mutex.Unlock()
 	tc.log.LogFail(failMsg, args...)
+	tc.capture("FAIL::", failMsg, args...)
+	tc.checkpoint(ResultFail, failMsg, args...)
 }
 
 func (tc *TestCase) LogWarning(warnMsg string, args ...interface{}) {
+	tc.mutex.Lock()
 	tc.warningCount++
+	tc.mutex.Unlock()
 	tc.log.LogWarning(warnMsg, args...)
+	tc.capture("WARNING::", warnMsg, args...)
+	tc.checkpoint(ResultWarning, warnMsg, args...)
 }
 
 func (tc *TestCase) LogPass(passMsg string, args ...interface{}) {
+	tc.mutex.Lock()
 	tc.passedCount++
+	tc.mutex.Unlock()
 	tc.log.LogPass(passMsg, args...)
+	tc.capture("PASS::", passMsg, args...)
+	tc.checkpoint(ResultPass, passMsg, args...)
 }
 
 func (tc *TestCase) LogMessage(msg string, args ...interface{}) {
 	tc.log.LogMessage(msg, args...)
+	tc.capture("MSG::", msg, args...)
 }
 
 func (tc *TestCase) LogDebug(debugMsg string, args ...interface{}) {
 	tc.log.LogDebug(debugMsg, args...)
 }
 
+// Output returns the log messages captured during the test run
+func (tc *TestCase) Output() string {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+	return tc.output.String()
+}
+
+func (tc *TestCase) capture(prefix, msg string, args ...interface{}) {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+	tc.output.WriteString(prefix)
+	tc.output.WriteString(formatLog(msg, args...))
+	tc.output.WriteString("\n")
+}
+
+// checkpoint sends the check point to the manager so report generators
+// that are CheckpointListener objects get it while the test runs
+func (tc *TestCase) checkpoint(level int, msg string, args ...interface{}) {
+	if n, ok := tc.parent.(checkpointNotifier); ok {
+		n.checkpointLogged(tc.suiteName, tc.name, level, formatLog(msg, args...))
+	}
+}
+
+// Context returns the context the test runs with. It is done when the
+// test times out or the run is cancelled
+func (tc *TestCase) Context() context.Context {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+	if tc.ctx == nil {
+		return context.Background()
+	}
+	return tc.ctx
+}
+
+// setContext is called by TestManager with the context for the test
+func (tc *TestCase) setContext(ctx context.Context) {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+	tc.ctx = ctx
+}
+
+// resetAttempt is called by TestManager before the test is retried
+func (tc *TestCase) resetAttempt() {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+	tc.passedCount = 0
+	tc.failedCount = 0
+	tc.warningCount = 0
+	tc.Critical = Section{}
+	tc.output.Reset()
+	tc.paramErrs = nil
+}
+
+// initAttempt is called by TestManager before Setup() of each attempt. An
+// invalid failureThreshold fails Setup() of every attempt, not just the first
+func (tc *TestCase) initAttempt() {
+	tc.failureThreshold = tc.RequireInt("failureThreshold")
+}
+
+// setSuiteName is called by TestManager with the suite the test runs in
+func (tc *TestCase) setSuiteName(name string) {
+	tc.suiteName = name
+}
+
+func formatLog(msg string, args ...interface{}) string {
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
 func (tc *TestCase) InitParam(name string, value interface{}) interface{} {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
 	return tc.params.InitParam(name, value)
 }
 
 func (tc *TestCase) AddParam(name string, value interface{}, comment string) interface{} {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
 	return tc.params.AddParam(name, value, comment)
 }
 
 func (tc *TestCase) GetParamValue(name string) (interface{}, bool) {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
 	return tc.params.GetParamValue(name)
 }
 
@@ -310,18 +540,29 @@ func (tc *TestCase) GetParams() *Parameters {
 	return &tc.params
 }
 
+// copyParams returns a copy of the params that is safe to take while a
+// timed out test is still running
+func (tc *TestCase) copyParams() Parameters {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+	return tc.params.copy()
+}
+
 func (tc *TestCase) ReturnFromRun() (int, error) {
 	var calcFailThreshold float64
+	tc.mutex.Lock()
 	totalTC := tc.passedCount + tc.failedCount
 	if totalTC <= 0 {
 		calcFailThreshold = 0
 	} else {
 		calcFailThreshold = float64((float64(tc.failedCount) / float64(totalTC))) * 100.00
 	}
+	critical := tc.Critical.Triggered()
+	tc.mutex.Unlock()
 
 	tc.LogMessage("test %s ran %d check points with failure rate of %.3f", tc.Name(), totalTC, calcFailThreshold)
 
-	if tc.Critical.Triggered() {
+	if critical {
 		tc.LogError("ERROR:: Found Critical error during run!")
 		return TcCriticalError, nil
 	}
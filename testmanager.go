@@ -5,7 +5,10 @@
 package goQA
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
 	"sync"
 	//"error"
 	//"os"
@@ -13,7 +16,8 @@ import (
 	"io"
 	"io/ioutil"
 	"reflect"
-	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-QA/logger"
@@ -24,6 +28,10 @@ const (
 	MaxTestrunWaitime = 30
 )
 
+// TimeoutParam is the test parameter that sets the test timeout.
+// The value is seconds as a number or a duration string like "1m30s"
+const TimeoutParam = "timeout"
+
 // --------------------  Default Registery  -----------------------
 
 // TestRegister interface is passed to TestManager in APIs like RunFromXML()
@@ -34,16 +42,23 @@ type TestRegister interface {
 }
 
 // DefaultRegister stores TypeOf(<testCase>) in a map:
-//  var registry map[string]reflect.Type
+//
+//	var registry map[string]reflect.Type
 //
 // Example Of seting up map with two test cases:
-//    var regTests map[string]reflect.Type = map[string]reflect.Type{
-//        "test1": reflect.TypeOf(Test1{}),
-//	      "test2": reflect.TypeOf(Test2{}),
-//	      "test3": reflect.TypeOf(Test3{})}
 //
+//	var regTests map[string]reflect.Type = map[string]reflect.Type{
+//		"test1": reflect.TypeOf(Test1{}),
+//		"test2": reflect.TypeOf(Test2{}),
+//		"test3": reflect.TypeOf(Test3{})}
+//
+// Suite types are stored the same way in Suites:
+//
+//	reg.Suites = map[string]reflect.Type{"MySuite": reflect.TypeOf(MySuite{})}
 type DefaultRegister struct {
 	Registry map[string]reflect.Type
+	Hooks    map[string]reflect.Type // ManagerHook types for <Hook class="..."> in test plans
+	Suites   map[string]reflect.Type // Suite types for <TestSuite class="..."> in test plans
 }
 
 // GetTestCase Creates the TestCase object and calls Init()
@@ -61,51 +76,152 @@ func (r *DefaultRegister) GetTestCase(testCaseName string) (Tester, error) {
 	return nil, Create(&Parameters{}, "invalid test class '"+testCaseName+"'")
 }
 
-// GetSuite Creates DefaultSuite  object and calls Init()
-// Suite interface is returned
-// error return testError
+// GetSuite Creates the Suite object registered as suiteClass in Suites and
+// calls Init(). "DefaultSuite", or no class, is a DefaultSuite unless
+// Suites has its own type for it
+// error returned for an unknown class
 func (r *DefaultRegister) GetSuite(suiteName string, suiteClass string, tm *TestManager, params Parameters) (Suite, error) {
-	suite := DefaultSuite{}
+	suiteType, ok := r.Suites[suiteClass]
+	if !ok {
+		if suiteClass != "" && suiteClass != "DefaultSuite" {
+			return nil, fmt.Errorf("invalid suite class '%s'", suiteClass)
+		}
+		suiteType = reflect.TypeOf(DefaultSuite{})
+	}
+	suite, ok := reflect.New(suiteType).Interface().(Suite)
+	if !ok {
+		return nil, fmt.Errorf("suite class '%s' does not implement Suite", suiteClass)
+	}
 	suite.Init(suiteName, tm, params)
-	return &suite, nil
+	return suite, nil
+}
+
+// HasTestCase returns true if testCaseClass is in Registry
+func (r *DefaultRegister) HasTestCase(testCaseClass string) bool {
+	_, ok := r.Registry[testCaseClass]
+	return ok
+}
+
+// HasSuite returns true for the suite classes GetSuite() creates, the Suite
+// types in Suites, "" and "DefaultSuite"
+func (r *DefaultRegister) HasSuite(suiteClass string) bool {
+	if suiteType, ok := r.Suites[suiteClass]; ok {
+		return reflect.PtrTo(suiteType).Implements(reflect.TypeOf((*Suite)(nil)).Elem())
+	}
+	return suiteClass == "" || suiteClass == "DefaultSuite"
 }
 
 // ---------------------------  Define XML for test plans -------------------
 
 // XMLParam can be TestCase, Suite, or Manager parameter
 type XMLParam struct {
-	Name    string `xml:"name,attr"`
-	Type    string `xml:"type,attr"`
-	Comment string `xml:"comment,attr"`
-	Value   string `xml:",chardata"`
+	Name    string `xml:"name,attr" json:"name" yaml:"name"`
+	Type    string `xml:"type,attr,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
+	Comment string `xml:"comment,attr,omitempty" json:"comment,omitempty" yaml:"comment,omitempty"`
+	GoType  string `xml:"goType,attr,omitempty" json:"goType,omitempty" yaml:"goType,omitempty"` // Go type of an int or float value, like "int"
+	Value   string `xml:",chardata" json:"value" yaml:"value"`
 }
 
 // XMLTestCase defines test case
 type XMLTestCase struct {
-	Name   string     `xml:"name,attr"`
-	Class  string     `xml:"class,attr"`
-	Params []XMLParam `xml:"Param"`
+	Name      string     `xml:"name,attr" json:"name" yaml:"name"`
+	Class     string     `xml:"class,attr" json:"class" yaml:"class"`
+	DependsOn string     `xml:"dependsOn,attr,omitempty" json:"dependsOn,omitempty" yaml:"dependsOn,omitempty"` // comma separated test names in the same suite
+	Tags      string     `xml:"tags,attr,omitempty" json:"tags,omitempty" yaml:"tags,omitempty"`                // comma separated tags
+	Params    []XMLParam `xml:"Param" json:"params,omitempty" yaml:"params,omitempty"`
+	Matrix    *XMLMatrix `xml:"Matrix" json:"matrix,omitempty" yaml:"matrix,omitempty"` // runs the test once for each combination of values
+	Data      *XMLData   `xml:"Data" json:"data,omitempty" yaml:"data,omitempty"`       // runs the test once for each row of a data file
 }
 
 // XMLTestSuite Defines Suite object with list of XMLTestCase and
 // suite params
 type XMLTestSuite struct {
-	Name      string        `xml:"name,attr"`
-	Class     string        `xml:"class,attr"`
-	Params    []XMLParam    `xml:"Param"`
-	TestCases []XMLTestCase `xml:"TestCase"`
+	Name      string        `xml:"name,attr" json:"name" yaml:"name"`
+	Class     string        `xml:"class,attr" json:"class" yaml:"class"`
+	Extends   string        `xml:"extends,attr,omitempty" json:"extends,omitempty" yaml:"extends,omitempty"` // name of a SuiteTemplate
+	Tags      string        `xml:"tags,attr,omitempty" json:"tags,omitempty" yaml:"tags,omitempty"`          // comma separated tags for the suite and its tests
+	Params    []XMLParam    `xml:"Param" json:"params,omitempty" yaml:"params,omitempty"`
+	TestCases []XMLTestCase `xml:"TestCase" json:"tests" yaml:"tests"`
+
+	file     string // plan file of a suite from an included plan, set by ExpandTestPlan()
+	location string // location of the suite in file
+}
+
+// XMLHook defines a ManagerHook created by a HookRegister, with hook params
+type XMLHook struct {
+	Class  string     `xml:"class,attr" json:"class" yaml:"class"`
+	Params []XMLParam `xml:"Param" json:"params,omitempty" yaml:"params,omitempty"`
 }
 
-// XMLTestPlan hold XMLSuite list
+// XMLTestPlan hold XMLSuite list. The same structure is read from
+// JSON and YAML plans, see ParseTestPlan()
 type XMLTestPlan struct {
-	XMLName xml.Name       `xml:"TestManager"`
-	Name    string         `xml:"name,attr"`
-	Params  []XMLParam     `xml:"Param"`
-	Suites  []XMLTestSuite `xml:"TestSuite"`
+	XMLName   xml.Name       `xml:"TestManager" json:"-" yaml:"-"`
+	Name      string         `xml:"name,attr" json:"name" yaml:"name"`
+	Includes  []XMLInclude   `xml:"Include" json:"includes,omitempty" yaml:"includes,omitempty"`
+	Params    []XMLParam     `xml:"Param" json:"params,omitempty" yaml:"params,omitempty"`
+	Hooks     []XMLHook      `xml:"Hook" json:"hooks,omitempty" yaml:"hooks,omitempty"`
+	Templates []XMLTestSuite `xml:"SuiteTemplate" json:"templates,omitempty" yaml:"templates,omitempty"`
+	Suites    []XMLTestSuite `xml:"TestSuite" json:"suites" yaml:"suites"`
+
+	file string // plan file, set by ParseTestPlan()
 }
 
 // --------------------------------------------------------------
 
+// outputCapturer is implemented by tests that keep their own log output,
+// like TestCase, so it can be added to the test results
+type outputCapturer interface {
+	Output() string
+}
+
+// paramHolder is implemented by tests that can return their Parameters,
+// like TestCase, so they can be added to the test results
+type paramHolder interface {
+	GetParams() *Parameters
+}
+
+// paramCopier is implemented by TestCase to copy its Parameters while a
+// timed out Setup() or Run() can still be changing them
+type paramCopier interface {
+	copyParams() Parameters
+}
+
+// suiteNameSetter is implemented by TestCase so it knows the suite it runs in
+type suiteNameSetter interface {
+	setSuiteName(name string)
+}
+
+// timeoutSuite is implemented by suites, like DefaultSuite, that override
+// the manager test timeout for their tests
+type timeoutSuite interface {
+	TestTimeout() time.Duration
+}
+
+// contextSetter is implemented by TestCase and DefaultSuite so Context()
+// returns the context they run with
+type contextSetter interface {
+	setContext(ctx context.Context)
+}
+
+// attemptResetter is implemented by TestCase to clear check point counts
+// and log output before a test is retried
+type attemptResetter interface {
+	resetAttempt()
+}
+
+// attemptInitializer is implemented by TestCase to check the params it
+// needs before each attempt of the test
+type attemptInitializer interface {
+	initAttempt()
+}
+
+// checkpointNotifier is implemented by TestManager to pass check points
+// logged by a TestCase on to report generators
+type checkpointNotifier interface {
+	checkpointLogged(suiteName, testName string, level int, msg string)
+}
+
 type Manager interface {
 	RunSuite(suite string) int
 	Run(suiteName string, tc Tester, chReport chan testResult)
@@ -121,12 +237,19 @@ type TestManager struct {
 	//sMu         sync.Mutex
 	//tcStartMu   sync.Mutex
 	//tcFinMu     sync.Mutex
-	suites     []Suite
-	report     ManagerResult
-	generators map[string]ReportWriter
-	log        *logger.GoQALog
-	suiteFlags int
-	testFlags  int
+	suites      []Suite
+	report      ManagerResult
+	generators  map[string]ReportWriter
+	log         *logger.GoQALog
+	suiteFlags  int
+	testFlags   int
+	testTimeout time.Duration
+	retryPolicy RetryPolicy
+	hooks       []ManagerHook
+	includes    []TestFilter
+	excludes    []TestFilter
+	overrides   []paramOverride // from AddParamOverrides() and AddParamsFile()
+	ctx         context.Context // context of RunAllContext(), guarded by mutex
 }
 
 // Init iTestManager interface method to do setup of Test Manager
@@ -135,12 +258,15 @@ func (tm *TestManager) Init(log io.Writer, reportWriter ReportWriter) *TestManag
 	tm.report = ManagerResult{}
 	tm.generators = make(map[string]ReportWriter)
 	tm.report.Init("report1")
+	tm.report.generators = tm.generators
 	tm.log = &logger.GoQALog{}
 	tm.log.Init()
 	tm.log.Add("default", logger.LogLevelAll, log)
+	tm.report.log = tm.log
 	//tr := TextReporter{}
-	reportWriter.Init(tm)
-	tm.addGenerator(reportWriter)
+	if reportWriter != nil {
+		tm.AddReporter(reportWriter)
+	}
 	return tm
 }
 
@@ -160,76 +286,330 @@ func (tm *TestManager) AddLogger(name string, level uint64, stream io.Writer) {
 	tm.log.Add(name, level, stream)
 }
 
-// Run will execute the TestCase and log test results to chReport
+// Run will execute the TestCase and log test results to chReport.
+// Setup() with Run() and then Teardown() each have to finish within the test
+// timeout (see getTestTimeout()). A test that overruns is reported as TcTimedOut,
+// its Teardown() is still attempted, and it is left running in the background.
+// So Teardown() can run while the timed out Setup() or Run() is still running on
+// the same test, and tests that can time out have to allow for that. The context
+// passed to a ContextTester, or returned by TestCase.Context(), is cancelled when
+// the test times out or the run is cancelled. A timed out test is only retried
+// once the method left running returns, which it is given the test timeout to
+// do; otherwise it isn't retried.
+// A test that doesn't pass is run again as set by its RetryPolicy (see getRetryPolicy())
 func (tm *TestManager) Run(suiteName string, tc Tester, chReport chan testResult) {
-	var (
-		runStatus, setupStatus, teardownStatus int
-		runErr, setupErr, teardownErr          error
-		inRunTest, inRunSetup, inRunTeardown   bool
-	)
+	result := tm.run(suiteName, tc)
 
-	inRunSetup = false
-	inRunTeardown = false
-	inRunTest = false
+	// results are reported by testResultHandler() from chReport
+	if chReport != nil {
+		chReport <- result
+	}
+}
 
+// run runs tc with retries and returns the result of the last attempt
+func (tm *TestManager) run(suiteName string, tc Tester) testResult {
+	if s, ok := tc.(suiteNameSetter); ok {
+		s.setSuiteName(suiteName)
+	}
+	if suiteName != "" {
+		tm.report.testStarted(suiteName, tc.Name())
+	}
+
+	timeout := tm.getTestTimeout(suiteName, tc)
+	policy := tm.getRetryPolicy(suiteName, tc)
+	attempts := []testResult{}
+	result, running := tm.runAttempt(tc, timeout)
+	for attempt := 1; attempt < policy.MaxAttempts && policy.retries(result.Status); attempt++ {
+		delay := policy.delay(attempt)
+		tm.log.LogMessage("Retrying test '%s' in %s::attempt %d of %d failed with %s",
+			tc.Name(), delay, attempt, policy.MaxAttempts, TcStatusName(result.Status))
+		select {
+		case <-time.After(delay):
+		case <-tm.context().Done():
+		}
+		if tm.context().Err() != nil {
+			break
+		}
+		// the test can't run twice at the same time
+		if !waitPhases(running, timeout) {
+			tm.log.LogWarning("Not retrying test '%s'::timed out attempt is still running", tc.Name())
+			result.StatusMessage += "; not retried, the timed out attempt is still running"
+			break
+		}
+		attempts = append(attempts, result)
+		if r, ok := tc.(attemptResetter); ok {
+			r.resetAttempt()
+		}
+		result, running = tm.runAttempt(tc, timeout)
+	}
+
+	result.attempts = attempts
+	if len(attempts) > 0 && result.Status == TcPassed {
+		result.Status = TcFlaky
+		result.StatusMessage = fmt.Sprintf("Test passed on attempt %d of %d", len(attempts)+1, policy.MaxAttempts)
+	}
+	if len(attempts) > 0 {
+		// reports the time of all attempts
+		result.start = attempts[0].start
+	}
+	return result
+}
+
+// runAttempt runs tc once and returns the result, with the channels of the
+// phases that timed out and are still running, see runPhases()
+func (tm *TestManager) runAttempt(tc Tester, timeout time.Duration) (testResult, []chan phaseResult) {
 	result := testResult{}
 	result.Init(tc.Name())
 
-	defer func() {
-		result.name = tc.Name()
-		result.end = time.Now()
-		if r := recover(); r != nil {
-			result.Status = TcError
-			if suiteName != "" {
-				if inRunTeardown {
-					//fmt.Printf("DEFERING::RECOVER::TEARDOWN=%d\n", setupStatus)
-					result.StatusMessage = fmt.Sprintf("Error caught During test Teardown::%s", r)
-					result.Status = TcTeardownError
-					tm.report.testTeardownError(suiteName, result)
-				} else if inRunTest {
-					//fmt.Printf("DEFERING::RECOVER::SETUP=%d\n", setupStatus)
-					result.StatusMessage = fmt.Sprintf("Error caught During test run%s", r)
-					tm.report.testError(suiteName, result)
-				} else if inRunSetup {
-					//fmt.Printf("DEFERING::RECOVER::SETUP=%d\n", setupStatus)
-					result.StatusMessage = fmt.Sprintf("Error caught During test Setup::%s", r)
-					result.Status = TcSetupError
-					tm.report.testSetupError(suiteName, result)
-				}
+	ctx, cancel := context.WithCancel(tm.context())
+	defer cancel()
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if c, ok := tc.(contextSetter); ok {
+		c.setContext(ctx)
+	}
+
+	test := NewContextTester(tc)
+	running := []chan phaseResult{}
+	body := tm.runPhases(ctx, test, timeout, phaseSetup, phaseRun)
+	if body.running != nil {
+		running = append(running, body.running)
+	}
+	switch {
+	case body.timedOut:
+		result.Status = TcTimedOut
+		result.StatusMessage = fmt.Sprintf("Test timed out after %s during %s", timeout, phaseNames[body.phase])
+	case body.recovered != nil && body.phase == phaseSetup:
+		result.Status = TcSetupError
+		result.StatusMessage = fmt.Sprintf("Error caught During test Setup::%s", body.recovered)
+	case body.recovered != nil:
+		result.Status = TcError
+		result.StatusMessage = fmt.Sprintf("Error caught During test run::%s", body.recovered)
+	case body.msg != "":
+		result.Status = body.status
+		result.StatusMessage = body.msg
+	default:
+		result.Status = body.status
+		result.StatusMessage = "Test complete"
+	}
+
+	// a panic in Setup() or Run() skips Teardown()
+	if body.recovered == nil {
+		// TeardownContext() gets its own timeout since the test context may be
+		// done. TestCase.Context() keeps the test context, which a timed out
+		// Setup() or Run() can still be using
+		tdCtx, tdCancel := context.WithCancel(context.Background())
+		if timeout > 0 {
+			tdCtx, tdCancel = context.WithTimeout(tdCtx, timeout)
+		}
+		teardown := tm.runPhases(tdCtx, test, timeout, phaseTeardown)
+		tdCancel()
+		if teardown.running != nil {
+			running = append(running, teardown.running)
+		}
+		if teardown.timedOut {
+			if result.Status != TcTimedOut {
+				result.Status = TcTimedOut
+				result.StatusMessage = ""
+			}
+			result.StatusMessage = strings.TrimPrefix(fmt.Sprintf("%s; Teardown timed out after %s", result.StatusMessage, timeout), "; ")
+		} else if teardown.recovered != nil {
+			if result.Status != TcTimedOut {
+				result.Status = TcTeardownError
+				result.StatusMessage = fmt.Sprintf("Error caught During test Teardown::%s", teardown.recovered)
 			} else {
-				result.StatusMessage = "Test complete"
-				result.Status = runStatus
+				result.StatusMessage = fmt.Sprintf("%s; Error caught During test Teardown::%s", result.StatusMessage, teardown.recovered)
+			}
+		}
+	}
+
+	result.name = tc.Name()
+	result.end = time.Now()
+	if c, ok := tc.(outputCapturer); ok {
+		result.output = c.Output()
+	}
+	if p, ok := tc.(paramCopier); ok {
+		result.params = p.copyParams()
+	} else if p, ok := tc.(paramHolder); ok {
+		result.params = p.GetParams().copy()
+	}
+	return result, running
+}
+
+// waitPhases waits up to timeout for the phases left running after a timeout
+// to return. false is returned when one is still running
+func waitPhases(running []chan phaseResult, timeout time.Duration) bool {
+	if len(running) == 0 {
+		return true
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for _, ch := range running {
+		select {
+		case <-ch:
+		case <-timer.C:
+			return false
+		}
+	}
+	return true
+}
+
+// Test phases run by TestManager.Run()
+const (
+	phaseSetup = iota + 1
+	phaseRun
+	phaseTeardown
+)
+
+var phaseNames = map[int32]string{
+	phaseSetup:    "Setup",
+	phaseRun:      "Run",
+	phaseTeardown: "Teardown",
+}
+
+// phaseResult is returned from runPhases()
+type phaseResult struct {
+	phase     int32       // phase that was running when it finished
+	status    int         // status returned by the last phase
+	recovered interface{} // value recovered from a panic
+	timedOut  bool
+	running   chan phaseResult // gets the result when timed out phases return
+	msg       string           // status message when the phases stopped early
+}
+
+// testerOf returns the test behind tc when it was adapted by NewContextTester()
+func testerOf(tc ContextTester) Tester {
+	if t, ok := tc.(*contextTester); ok {
+		return t.Tester
+	}
+	return tc
+}
+
+// paramErrors returns the required param errors of the test behind tc
+func paramErrors(tc ContextTester) []string {
+	if h, ok := testerOf(tc).(paramErrorHolder); ok {
+		return h.paramErrors()
+	}
+	return nil
+}
+
+// runPhases calls the test methods for phases in order in its own goroutine
+// and waits until they are done or timeout expires. timeout <= 0 waits forever.
+// The goroutine is left running after a timeout, and sends on the running
+// channel of the result when it returns
+func (tm *TestManager) runPhases(ctx context.Context, tc ContextTester, timeout time.Duration, phases ...int32) phaseResult {
+	var phase int32
+	chDone := make(chan phaseResult, 1)
+
+	go func() {
+		var result phaseResult
+		defer func() {
+			if r := recover(); r != nil {
+				result.recovered = r
+			}
+			result.phase = atomic.LoadInt32(&phase)
+			chDone <- result
+		}()
+
+		for _, p := range phases {
+			var err error
+			atomic.StoreInt32(&phase, p)
+			switch p {
+			case phaseSetup:
+				if err := bindTest(testerOf(tc)); err != nil {
+					// reported like a panic in Setup(), so Run() and Teardown() are skipped
+					result.recovered = fmt.Sprintf("Unable to bind params::%s", err.Error())
+					return
+				}
+				if i, ok := testerOf(tc).(attemptInitializer); ok {
+					i.initAttempt()
+				}
+				result.status, err = tc.SetupContext(ctx)
+				if err == nil {
+					tm.log.LogMessage("TestManager->setup::results=%d", result.status)
+				}
+				if errs := paramErrors(tc); len(errs) > 0 {
+					// a required param is missing, so the test can't run
+					result.status = TcSetupFailed
+					result.msg = strings.Join(errs, "; ")
+					return
+				}
+			case phaseRun:
+				result.status, err = tc.RunContext(ctx)
+				if err == nil {
+					tm.log.LogMessage("TestManager->Run::results=%d", result.status)
+				}
+			case phaseTeardown:
+				result.status, err = tc.TeardownContext(ctx)
+				if err == nil {
+					tm.log.LogMessage("TestManager->Teardown::results=%d", result.status)
+				}
 			}
-		} else {
-			result.StatusMessage = "Test complete"
-			result.Status = runStatus
 		}
-		chReport <- result
 	}()
 
-	if suiteName != "" {
-		tm.report.testStarted(suiteName, tc.Name())
+	if timeout <= 0 {
+		return <-chDone
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case result := <-chDone:
+		return result
+	case <-timer.C:
+		tm.log.LogError("Test '%s' timed out after %s", tc.Name(), timeout)
+		return phaseResult{phase: atomic.LoadInt32(&phase), timedOut: true, running: chDone}
 	}
+}
 
-	inRunSetup = true
-	setupStatus, setupErr = tc.Setup()
-	if setupErr == nil {
-		tm.log.LogMessage("TestManager->setup::results=%d", setupStatus)
+// getTestTimeout returns the timeout for tc. The "timeout" test parameter is
+// used first, then the TestTimeout() of the suite and last the manager default.
+// 0 means no timeout
+func (tm *TestManager) getTestTimeout(suiteName string, tc Tester) time.Duration {
+	if p, ok := tc.(paramHolder); ok {
+		if value, found := p.GetParams().GetParamValue(TimeoutParam); found {
+			timeout, err := toDuration(value)
+			if err == nil {
+				return timeout
+			}
+			tm.log.LogWarning("Test '%s' has invalid %s parameter::error=%s", tc.Name(), TimeoutParam, err.Error())
+		}
 	}
-	//  panic("Panicing in Setup")
-	inRunTest = true
-	runStatus, runErr = tc.Run()
-	if runErr == nil {
-		tm.log.LogMessage("TestManager->Run::results=%d", runStatus)
+	if suite, ok := tm.GetSuite(suiteName).(timeoutSuite); ok && suite.TestTimeout() > 0 {
+		return suite.TestTimeout()
 	}
+	return tm.testTimeout
+}
 
-	inRunTeardown = true
-	teardownStatus, teardownErr = tc.Teardown()
-	if teardownErr == nil {
-		tm.log.LogMessage("TestManager->Teardown::results=%d", teardownStatus)
+// SetTestTimeout sets the default time each test has to finish Setup() and Run().
+// Suites and the "timeout" test parameter can override it. 0 means no timeout
+func (tm *TestManager) SetTestTimeout(timeout time.Duration) {
+	tm.testTimeout = timeout
+}
+
+// context returns the context of the running RunAllContext() or
+// context.Background() when not running
+func (tm *TestManager) context() context.Context {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	if tm.ctx == nil {
+		return context.Background()
 	}
-	// panic("Panicing in Teardown")
+	return tm.ctx
+}
+
+// setContext sets the context returned by context()
+func (tm *TestManager) setContext(ctx context.Context) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.ctx = ctx
+}
 
+// TestTimeout returns the default test timeout set by SetTestTimeout()
+func (tm *TestManager) TestTimeout() time.Duration {
+	return tm.testTimeout
 }
 
 // RunTest is same as Run() but takes Suite name and TestCase name as arguments
@@ -250,17 +630,25 @@ func (tm *TestManager) runSuite(suiteName string, chSuiteResults chan int) {
 	inSuiteSetup := false
 	inSuiteTeardown := false
 	inSuiteRuntests := false
-	var guard chan struct{}
 
 	inSuiteSetup = false
 	inSuiteTeardown = false
-	chComplete := make(chan int)
+	chComplete := make(chan int, 1)
 	chReport := make(chan testResult)
 
 	suite := tm.GetSuite(suiteName)
+
+	// a suite without tests selected by the filters is skipped without Setup()
+	reasons := tm.filterReasons(suite)
+	if len(reasons) > 0 && !hasSelected(reasons) {
+		tm.log.LogMessage("Skipping Suite '%s'::no tests selected by filters", suiteName)
+		tm.skipSuite(suiteName, "No tests selected by filters", reasons, chSuiteResults)
+		return
+	}
+
 	tm.log.LogMessage("Running  Suite '%s'\n", suiteName)
 
-	go tm.testResultHandler(suiteName, chReport)
+	go tm.testResultHandler(suiteName, chReport, chComplete)
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -292,9 +680,31 @@ func (tm *TestManager) runSuite(suiteName string, chSuiteResults chan int) {
 
 	tm.report.suiteStarted(suite.Name(), "")
 
+	// a suite with unknown test dependencies or a cycle doesn't run
+	graph, err := newTestGraph(suite)
+	if err != nil {
+		tm.log.LogError("Unable to run suite '%s'::error=%s", suiteName, err.Error())
+		close(chReport)
+		_ = <-chComplete
+		tm.report.suiteFailed(suite.Name(), err.Error())
+		chSuiteResults <- SuiteFailed
+		return
+	}
+	copy(graph.skip, reasons)
+
+	// a suite that starts after the run is cancelled only reports its tests skipped
+	ctx := tm.context()
+	cancelled := ctx.Err() != nil
+	cSuite := NewContextSuite(suite)
+	if c, ok := suite.(contextSetter); ok {
+		c.setContext(ctx)
+	}
+
 	// Suite Setup
 	inSuiteSetup = true
-	if status, msg, err := suite.Setup(); err == nil {
+	if cancelled {
+		// skip setup
+	} else if status, msg, err := cSuite.SetupContext(ctx); err == nil {
 		if status == SuiteSetupFailed {
 			tm.report.suiteSetupFailed(suite.Name(), msg)
 		}
@@ -305,56 +715,21 @@ func (tm *TestManager) runSuite(suiteName string, chSuiteResults chan int) {
 	// Run Tests
 	inSuiteRuntests = true
 
-	done := make(chan int, 5)
-	finished := make(chan int)
-	testCount := len(suite.GetTestCases())
-
-	// Setup to run tests in parallel
-	// create guard chan to throttle tests and launch goroutine
-	// that returns <- finished when all tests complete
-	if tm.testFlags != TcSerial {
-
-		// .testFlags is number of test cases to run concurrently
-		if tm.testFlags != TcAll {
-			guard = make(chan struct{}, tm.testFlags)
-		}
-
-		// Launch a go routine to wait for last test then
-		//then send message on finished channel
-		go func() {
-			completedTests := 0
-			for _ = range done {
-				completedTests++
-				if completedTests >= testCount {
-					break
-				}
-			}
-			finished <- 1
-		}()
-
-	}
+	tm.runTests(suiteName, graph, chReport)
 
-	for _, tc := range tm.GetSuite(suiteName).GetTestCases() {
-		tm.log.LogMessage("Running test '%s'", tc.Name())
-		if tm.testFlags == TcAll {
-			go tm.launchTest(suiteName, tc, done, chReport)
-		} else if tm.testFlags == TcSerial {
-			tm.Run(suiteName, tc, chReport)
-		} else {
-			guard <- struct{}{}
-			go tm.launchTestWithGuard(suiteName, tc, guard, done, chReport)
-		}
-	}
+	close(chReport)
+	// wait for all results to be reported before suite finishes
+	_ = <-chComplete
 
-	if tm.testFlags != TcSerial {
-		_ = <-finished
+	if cancelled {
+		tm.report.suiteSkipped(suite.Name(), "Run cancelled")
+		chSuiteResults <- SuiteSkipped
+		return
 	}
 
-	close(chReport)
-
 	// Suite Teardown()
 	inSuiteTeardown = true
-	if status, msg, err := suite.Teardown(); err == nil {
+	if status, msg, err := cSuite.TeardownContext(ctx); err == nil {
 		if status == SuiteTeardownFailed {
 			tm.report.suiteTeardownFailed(suite.Name(), msg)
 			chSuiteResults <- SuiteTeardownFailed
@@ -368,18 +743,22 @@ func (tm *TestManager) runSuite(suiteName string, chSuiteResults chan int) {
 	}
 }
 
-func (tm *TestManager) launchTest(suiteName string, testcase Tester, done chan int, chReport chan testResult) {
-	tm.Run(suiteName, testcase, chReport)
-	done <- 1
-}
-
-func (tm *TestManager) launchTestWithGuard(suiteName string, testcase Tester, guard chan struct{}, done chan int, chReport chan testResult) {
-	tm.Run(suiteName, testcase, chReport)
-	<-guard
-	done <- 1
+// skipTest reports tc as TcSkipped with msg without running it
+func (tm *TestManager) skipTest(suiteName string, tc Tester, msg string, chReport chan testResult) {
+	tm.log.LogMessage("Skipping test '%s'::%s", tc.Name(), msg)
+	tm.report.testStarted(suiteName, tc.Name())
+	result := testResult{}
+	result.Init(tc.Name())
+	result.Status = TcSkipped
+	result.StatusMessage = msg
+	result.end = time.Now()
+	if p, ok := tc.(paramHolder); ok {
+		result.params = p.GetParams().copy()
+	}
+	chReport <- result
 }
 
-func (tm *TestManager) testResultHandler(suiteName string, chResult chan testResult) {
+func (tm *TestManager) testResultHandler(suiteName string, chResult chan testResult, chComplete chan int) {
 	var result testResult
 	//fmt.Printf("LENGTH=%d\n", length)
 	for result = range chResult {
@@ -403,53 +782,109 @@ func (tm *TestManager) testResultHandler(suiteName string, chResult chan testRes
 		case TcTeardownFailed:
 			tm.report.testTeardownFailed(suiteName, result)
 		case TcTeardownError:
-			tm.report.testSetupError(suiteName, result)
+			tm.report.testTeardownError(suiteName, result)
+		case TcTimedOut:
+			tm.report.testTimedOut(suiteName, result)
+		case TcFlaky:
+			tm.report.testFlaky(suiteName, result)
 		}
 
 	}
+	chComplete <- 1
 
 }
 
-// RunAll will run all testplans and all suites
+// RunAll will run all testplans and all suites.
+// SIGINT cancels the run, see RunAllContext()
 func (tm *TestManager) RunAll() {
+	tm.RunAllContext(context.Background())
+}
+
+// RunAllContext will run all suites like RunAll() until ctx is cancelled
+// or SIGINT is received. A cancelled run stops scheduling new tests and
+// reports them as TcSkipped, and cancels the context of the running tests.
+// A second SIGINT is not caught
+func (tm *TestManager) RunAllContext(ctx context.Context) {
 	chSuiteResults := make(chan int)
 	chComplete := make(chan int)
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tm.setContext(ctx)
+	defer tm.setContext(nil)
+
+	chSignal := make(chan os.Signal, 1)
+	signal.Notify(chSignal, os.Interrupt)
+	defer signal.Stop(chSignal)
+	go func() {
+		select {
+		case <-chSignal:
+			signal.Stop(chSignal)
+			tm.log.LogWarning("Interrupted, cancelling run...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
 	tm.report.managerStarted("Test Manager")
+
+	// test dependencies are checked before any suite runs
+	if err := tm.ValidateDependencies(); err != nil {
+		tm.log.LogError("Unable to run suites::error=%s", err.Error())
+		tm.report.managerFailed("Test Manager", err.Error())
+		tm.managerStatistics("Test Manager", "")
+		tm.log.Sync()
+		return
+	}
+
+	// Setup() of manager hooks; all suites are skipped when one doesn't pass
+	hooksSetUp, setup := tm.setupHooks()
+	if setup.status != ManagerPassed {
+		tm.log.LogError("Manager setup did not pass, skipping all suites::%s", setup.msg)
+	}
+
 	length := len(tm.suites)
 	go tm.endManagerHandler(chSuiteResults, chComplete, length)
 
-	tm.log.LogMessage("Running all suitess...")
-	if tm.suiteFlags != SuiteAll && tm.suiteFlags != SuiteSerial {
-		tm.suiteRunner(chSuiteResults)
-	} else {
+	if setup.status != ManagerPassed {
 		for _, suite := range tm.suites {
-			if tm.suiteFlags == SuiteAll {
-				go tm.runSuite(suite.Name(), chSuiteResults)
-			} else if tm.suiteFlags == SuiteSerial {
-				tm.runSuite(suite.Name(), chSuiteResults)
+			tm.skipSuite(suite.Name(), "Manager setup did not pass", nil, chSuiteResults)
+		}
+	} else {
+		tm.log.LogMessage("Running all suitess...")
+		if tm.suiteFlags != SuiteAll && tm.suiteFlags != SuiteSerial {
+			tm.suiteRunner(chSuiteResults)
+		} else {
+			for _, suite := range tm.suites {
+				if tm.suiteFlags == SuiteAll {
+					go tm.runSuite(suite.Name(), chSuiteResults)
+				} else if tm.suiteFlags == SuiteSerial {
+					tm.runSuite(suite.Name(), chSuiteResults)
+				}
 			}
 		}
 	}
 	_ = <-chComplete
-	tm.report.managerPassed("Test Manager", "")
-	tm.managerStatistics("Test Manager", "")
-	tm.log.Sync()
-}
 
-func (tm *TestManager) convertToParamType(value, paramType string) interface{} {
-	var convertedVal interface{}
-	switch paramType {
-	case "int":
-		convertedVal, _ = strconv.ParseInt(value, 10, 64)
-	case "float":
-		convertedVal, _ = strconv.ParseFloat(value, 64)
-	case "string":
-		convertedVal = value
+	// Teardown() of manager hooks always runs for hooks that were set up
+	teardown := tm.teardownHooks(hooksSetUp)
+
+	switch {
+	case setup.status == ManagerSetupFailed:
+		tm.report.managerSetUpFailed("Test Manager", setup.msg)
+	case setup.status == ManagerSetupError:
+		tm.report.managerSetUpError("Test Manager", setup.msg)
+	case ctx.Err() != nil:
+		tm.report.managerFailed("Test Manager", "Run cancelled")
+	case teardown.status == ManagerTeardownFailed:
+		tm.report.managerTearDownFailed("Test Manager", teardown.msg)
+	case teardown.status == ManagerTeardownError:
+		tm.report.managerTearDownError("Test Manager", teardown.msg)
 	default:
-		convertedVal = value
+		tm.report.managerPassed("Test Manager", "")
 	}
-	return convertedVal
+	tm.managerStatistics("Test Manager", "")
+	tm.log.Sync()
 }
 
 // ParseTestPlanFromXML tries to read a test plan from XML fileName
@@ -472,76 +907,133 @@ func (tm *TestManager) ParseTestPlanFromXML(fileName string, testPlan *XMLTestPl
 
 // AddTestPlan takes data stored in XMLTestPlan object and adds new suites with tests to Manager
 // Suite objects and Test Cases created from TestRegistry interface object
-// return nil on success or error
+// return nil on success or error. The plan is checked by ValidateTestPlan() first
+// and PlanErrors is returned for an invalid plan
 func (tm *TestManager) AddTestPlan(testPlan *XMLTestPlan, registry TestRegister) error {
 
-	var test Tester
 	tm.log.LogDebug("%v", testPlan)
-	var testParams, suiteParams, MngrParams *Parameters
+	if err := tm.ValidateTestPlan(testPlan, registry); err != nil {
+		return err
+	}
+	var suiteParams, MngrParams *Parameters
+	overrides, err := tm.paramOverrides()
+	if err != nil {
+		return err
+	}
+	tm.checkOverrides(testPlan, overrides)
 
-	MngrParams = new(Parameters)
-	MngrParams.Init()
+	mngrSource := ParamSource{Kind: ParamFromManager, Location: "/TestManager"}
+	MngrParams, _ = resolveParams(testPlan.Params, mngrSource, nil, overrides, "")
 	for _, param := range testPlan.Params {
-		MngrParams.AddParam(param.Name, tm.convertToParamType(param.Value, param.Type), param.Comment)
-		tm.log.LogDebug("MANAGERPARAM name=%s, type=%s,value= %s, comment=%s", param.Name, param.Type, param.Value, param.Comment)
+		tm.log.LogDebug("MANAGERPARAM name=%s, type=%s,value= %v, comment=%s", param.Name, param.Type, MngrParams.params[param.Name].value, param.Comment)
+	}
+	if err := tm.addXMLHooks(testPlan, registry, MngrParams, overrides); err != nil {
+		return err
 	}
 
 	for _, xmlSuite := range testPlan.Suites {
 
-		suiteParams = new(Parameters)
-		suiteParams.Init()
+		// ValidateTestPlan() reported any errors of the params
+		suiteLocation := planLocation("/TestManager", "TestSuite", xmlSuite.Name)
+		suiteSource := ParamSource{Kind: ParamFromSuite, Location: suiteLocation}
+		suiteParams, _ = resolveParams(xmlSuite.Params, suiteSource, MngrParams, overrides, xmlSuite.Name)
 		for _, param := range xmlSuite.Params {
-			suiteParams.AddParam(param.Name, tm.convertToParamType(param.Value, param.Type), param.Comment)
-			tm.log.LogDebug("SUITEPARAM name=%s, type=%s,value= %s, comment=%s", param.Name, param.Type, param.Value, param.Comment)
+			tm.log.LogDebug("SUITEPARAM name=%s, type=%s,value= %v, comment=%s", param.Name, param.Type, suiteParams.params[param.Name].value, param.Comment)
 		}
-		for k, v := range MngrParams.params {
-			if _, ok := suiteParams.params[k]; !ok {
-				suiteParams.params[k] = v
+
+		// overrides go on top of the merged plan params of each suite and test
+		effectiveParams := suiteParams.copy()
+		if err := applyOverrides(&effectiveParams, overrides, xmlSuite.Name); err != nil {
+			return err
+		}
+		suite, err := registry.GetSuite(xmlSuite.Name, xmlSuite.Class, tm, effectiveParams)
+		if err != nil {
+			return err
+		}
+		if xmlSuite.Tags != "" {
+			ts, ok := suite.(tagSuite)
+			if !ok {
+				return fmt.Errorf("suite '%s' does not support tags", xmlSuite.Name)
 			}
+			ts.AddTags(splitList(xmlSuite.Tags)...)
 		}
 
-		suite, _ := registry.GetSuite(xmlSuite.Name, xmlSuite.Class, tm, *suiteParams)
-
 		for _, xmlTest := range xmlSuite.TestCases {
-			testParams = new(Parameters)
-			testParams.Init()
-			for _, param := range xmlTest.Params {
-				testParams.AddParam(param.Name, tm.convertToParamType(param.Value, param.Type), param.Comment)
-				tm.log.LogDebug("TESTPARAM name=%s, type=%s,value= %s, comment=%s", param.Name, param.Type, param.Value, param.Comment)
-			}
-			for k, v := range suiteParams.params {
-				if _, ok := testParams.params[k]; !ok {
-					testParams.params[k] = v
+			testLocation := planLocation(suiteLocation, "TestCase", xmlTest.Name)
+			if xmlTest.Matrix == nil && xmlTest.Data == nil {
+				if err := tm.addXMLTest(registry, suite, xmlSuite.Name, xmlTest, xmlTest.Name, testLocation, suiteParams, overrides); err != nil {
+					return err
 				}
+				continue
 			}
 
-			test, _ = registry.GetTestCase(xmlTest.Class)
-			suite.AddTest(test, xmlTest.Name, *testParams)
+			ms, ok := suite.(matrixSuite)
+			if !ok {
+				return fmt.Errorf("suite '%s' does not support matrix or data tests", xmlSuite.Name)
+			}
+			generated, _ := testPlan.generatedTests(xmlTest, testLocation)
+			for _, g := range generated {
+				parent := matrixParams(g.values, g.source, suiteParams)
+				if err := tm.addXMLTest(registry, suite, xmlSuite.Name, xmlTest, g.name, testLocation, parent, overrides, xmlTest.Name); err != nil {
+					return err
+				}
+				ms.addMatrixTest(xmlTest.Name, g.name)
+			}
+		}
+		if _, err := newTestGraph(suite); err != nil {
+			return err
 		}
 		tm.AddSuite(suite)
 	}
 	return nil
 }
 
-// RunFromXML takes XML runplan file runs the suites with test cases by calling RunAll()
-func (tm *TestManager) RunFromXML(fileName string, registry TestRegister) error {
-	var testPlan XMLTestPlan
-	err := tm.ParseTestPlanFromXML(fileName, &testPlan)
-	if err != nil {
-		tm.log.LogError("Unable to parse XML file %s: error=%s", fileName, err.Error())
+// addXMLTest adds the test of xmlTest named name to suite, with its params
+// merged with parent. A test generated from a matrix or data file has the
+// name of the test case in matrixName, for the overrides of that test
+func (tm *TestManager) addXMLTest(registry TestRegister, suite Suite, suiteName string, xmlTest XMLTestCase,
+	name, location string, parent *Parameters, overrides []paramOverride, matrixName ...string) error {
+	testNames := append(append([]string{}, matrixName...), name)
+	testSource := ParamSource{Kind: ParamFromTest, Location: location}
+	testParams, _ := resolveParams(xmlTest.Params, testSource, parent, overrides, suiteName, testNames...)
+	for _, param := range xmlTest.Params {
+		tm.log.LogDebug("TESTPARAM name=%s, type=%s,value= %v, comment=%s", param.Name, param.Type, testParams.params[param.Name].value, param.Comment)
+	}
+	if err := applyOverrides(testParams, overrides, suiteName, testNames...); err != nil {
 		return err
 	}
 
-	err = tm.AddTestPlan(&testPlan, registry)
+	test, err := registry.GetTestCase(xmlTest.Class)
 	if err != nil {
-		tm.log.LogError("Unable to add test plan::error=%s", err.Error())
 		return err
 	}
-	tm.RunAll()
+	suite.AddTest(test, name, *testParams)
+
+	if xmlTest.Tags != "" {
+		ts, ok := suite.(tagSuite)
+		if !ok {
+			return fmt.Errorf("suite '%s' does not support tags", suiteName)
+		}
+		ts.AddTestTags(name, splitList(xmlTest.Tags)...)
+	}
 
+	if xmlTest.DependsOn != "" {
+		ds, ok := suite.(dependencySuite)
+		if !ok {
+			return fmt.Errorf("suite '%s' does not support test dependencies", suiteName)
+		}
+		ds.AddDependency(name, splitList(xmlTest.DependsOn)...)
+	}
 	return nil
 }
 
+// RunFromXML takes XML runplan file runs the suites with test cases by calling RunAll().
+// JSON and YAML plans are read by file extension, like RunFromPlan(). overrides
+// replace params of the plan, see AddParamOverrides()
+func (tm *TestManager) RunFromXML(fileName string, registry TestRegister, overrides ...map[string]string) error {
+	return tm.RunFromPlan(fileName, registry, overrides...)
+}
+
 func (tm *TestManager) endManagerHandler(chSuiteResult chan int, chComplete chan int, length int) {
 	// var result int
 	//fmt.Printf("Manager Length = %d\n", length)
@@ -577,27 +1069,97 @@ func (tm *TestManager) GetLogger() *logger.GoQALog {
 	return tm.log
 }
 
+// checkpointLogged passes check points logged by tests to report generators
+func (tm *TestManager) checkpointLogged(suiteName, testName string, level int, msg string) {
+	if suiteName != "" {
+		tm.report.checkpointLogged(suiteName, testName, level, msg)
+	}
+}
+
+// AddReporter calls Init() on reportWriter and adds it to the report generators
+// of the manager. Any number of reporters can be added, each with its own output.
+// Returns the name the reporter is added under, which is reportWriter.Name()
+// with a number added if another reporter already has that name
+func (tm *TestManager) AddReporter(reportWriter ReportWriter) string {
+	reportWriter.Init(tm)
+	return tm.addGenerator(reportWriter)
+}
+
+// RemoveReporter removes reportWriter from the report generators of the manager.
+// Returns false if reportWriter was not added to the manager
+func (tm *TestManager) RemoveReporter(reportWriter ReportWriter) bool {
+	tm.report.mutex.Lock()
+	defer tm.report.mutex.Unlock()
+	for name, gen := range tm.generators {
+		if gen == reportWriter {
+			delete(tm.generators, name)
+			return true
+		}
+	}
+	return false
+}
+
+// Reporters returns the report generators of the manager
+func (tm *TestManager) Reporters() []ReportWriter {
+	tm.report.mutex.Lock()
+	defer tm.report.mutex.Unlock()
+	reporters := make([]ReportWriter, 0, len(tm.generators))
+	for _, gen := range tm.generators {
+		reporters = append(reporters, gen)
+	}
+	return reporters
+}
+
 // addGenerator adds a report generator for manager
-func (tm *TestManager) addGenerator(gen ReportWriter) {
-	tm.generators[gen.Name()] = gen
+func (tm *TestManager) addGenerator(gen ReportWriter) string {
+	tm.report.mutex.Lock()
+	defer tm.report.mutex.Unlock()
+	name := gen.Name()
+	for i := 2; ; i++ {
+		if _, ok := tm.generators[name]; !ok {
+			break
+		}
+		name = fmt.Sprintf("%s#%d", gen.Name(), i)
+	}
+	tm.generators[name] = gen
+	return name
 }
 
 // managerStatistics will call the PerformManagerStatistics() interface for each report generator
 func (tm *TestManager) managerStatistics(name, msg string) {
 	//fmt.Printf("In->managerStatistics()  %p\n", tm.log)
-	genCount := len(tm.generators)
+	generators := tm.Reporters()
+	genCount := len(generators)
 	arComplete := make([]chan int, genCount)
-	index := 0
-	for _, generator := range tm.generators {
-		arComplete[index] = make(chan int)
-		go generator.PerformManagerStatistics(&tm.report, name, msg, arComplete[index])
-		index++
+	for index, generator := range generators {
+		// buffered so a reporter that panics after it is complete doesn't block
+		arComplete[index] = make(chan int, 1)
+		go tm.performStatistics(generator, name, msg, arComplete[index])
 	}
 	for i := 0; i < genCount; i++ {
 		_ = <-arComplete[i]
 	}
 }
 
+// performStatistics calls PerformManagerStatistics() for generator. A panic
+// in the generator is logged so the other reports are still completed.
+// A generator can panic after it sent on complete, so the send after a panic
+// doesn't block. complete has a buffer of one, so it is skipped while the
+// generator's value is in the buffer and lands in the empty buffer otherwise
+func (tm *TestManager) performStatistics(generator ReportWriter, name, msg string, complete chan int) {
+	defer func() {
+		if r := recover(); r != nil {
+			tm.log.LogError("Report generator '%s' failed::error=%s", generator.Name(), r)
+			select {
+			case complete <- 1:
+			default:
+				// the generator was complete before it panicked
+			}
+		}
+	}()
+	generator.PerformManagerStatistics(&tm.report, name, msg, complete)
+}
+
 // NewManager will create a new manager and call Init()
 // return the TestManager object
 func NewManager(stream io.Writer, reporter ReportWriter, suiteFlags int, testFlags int) (tm TestManager) {
@@ -0,0 +1,262 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestManager returns a serial TestManager that logs nowhere, with reporters
+func newTestManager(reporters ...ReportWriter) *TestManager {
+	tm := NewManager(ioutil.Discard, nil, SuiteSerial, TcSerial)
+	for _, r := range reporters {
+		tm.AddReporter(r)
+	}
+	return &tm
+}
+
+// addCheckSuite adds a suite with one passing checkTest to tm
+func addCheckSuite(tm *TestManager, name string) {
+	suite := NewSuite(name, tm, Parameters{})
+	suite.AddTest(&checkTest{checks: []bool{true}}, "test1", Parameters{})
+	tm.AddSuite(suite)
+}
+
+func TestAddRemoveReporter(t *testing.T) {
+	tm := NewManager(ioutil.Discard, nil, SuiteSerial, TcSerial)
+	first, second := &recordingReporter{}, &recordingReporter{}
+	if name := tm.AddReporter(first); name != "recordingReporter" {
+		t.Errorf("first reporter added as %q", name)
+	}
+	if name := tm.AddReporter(second); name != "recordingReporter#2" {
+		t.Errorf("second reporter with the same name added as %q", name)
+	}
+	if n := len(tm.Reporters()); n != 2 {
+		t.Errorf("%d reporters, want 2", n)
+	}
+
+	addCheckSuite(&tm, "suite1")
+	tm.RunAll()
+	if len(first.events) == 0 || len(second.events) == 0 {
+		t.Errorf("both reporters should get the events")
+	}
+
+	if !tm.RemoveReporter(first) {
+		t.Errorf("RemoveReporter didn't find the reporter")
+	}
+	if tm.RemoveReporter(first) {
+		t.Errorf("reporter removed twice")
+	}
+	if reporters := tm.Reporters(); len(reporters) != 1 || reporters[0] != second {
+		t.Errorf("reporters after remove %v", reporters)
+	}
+}
+
+func TestPerformStatisticsPanicAfterComplete(t *testing.T) {
+	tm := NewManager(ioutil.Discard, nil, SuiteSerial, TcSerial)
+	complete := make(chan int, 1)
+	done := make(chan bool)
+	go func() {
+		tm.performStatistics(&recordingReporter{panics: true}, "Test Manager", "", complete)
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("performStatistics blocked after the reporter panicked")
+	}
+	if n := len(complete); n != 1 {
+		t.Errorf("%d values on complete, want 1", n)
+	}
+}
+
+func TestPanickingReporterDoesNotStopOthers(t *testing.T) {
+	broken := &recordingReporter{name: "broken", panics: true}
+	other := &recordingReporter{name: "other"}
+	tm := NewManager(ioutil.Discard, broken, SuiteSerial, TcSerial)
+	tm.AddReporter(other)
+	addCheckSuite(&tm, "suite1")
+
+	done := make(chan bool)
+	go func() {
+		tm.RunAll()
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunAll blocked on a reporter that panicked")
+	}
+	if last := other.events[len(other.events)-1]; last != "statistics Test Manager" {
+		t.Errorf("other reporter didn't get the statistics, last event %q", last)
+	}
+}
+
+// sleepingTest sleeps in Run() and logs a check point when it wakes up,
+// which is after its timeout when it times out
+type sleepingTest struct {
+	TestCase
+	sleep time.Duration
+	done  chan bool // closed when Run() returns, if set
+}
+
+func (t *sleepingTest) Run() (int, error) {
+	if t.done != nil {
+		defer close(t.done)
+	}
+	time.Sleep(t.sleep)
+	t.LogPass("woke up after %s", t.sleep)
+	return t.ReturnFromRun()
+}
+
+// runTest runs tc with tm.Run() and returns its result
+func runTest(tm *TestManager, suiteName string, tc Tester) testResult {
+	chReport := make(chan testResult, 1)
+	tm.Run(suiteName, tc, chReport)
+	return <-chReport
+}
+
+func TestTestTimeouts(t *testing.T) {
+	tm := NewManager(ioutil.Discard, nil, SuiteSerial, TcSerial)
+	tm.SetTestTimeout(time.Hour)
+	suite := NewSuite("suite1", &tm, Parameters{})
+	suite.SetTestTimeout(20 * time.Millisecond)
+	slow := &sleepingTest{sleep: 200 * time.Millisecond, done: make(chan bool)}
+	suite.AddTest(slow, "slow", Parameters{})
+	params := Parameters{}
+	params.AddParam(TimeoutParam, "2s", "")
+	suite.AddTest(&sleepingTest{sleep: 50 * time.Millisecond}, "allowed", params)
+	tm.AddSuite(suite)
+	tm.report.suiteStarted("suite1", "")
+
+	result := runTest(&tm, "suite1", suite.GetTestCase("slow"))
+	if result.Status != TcTimedOut || !strings.Contains(result.StatusMessage, "during Run") {
+		t.Errorf("slow test is %s: %s, want TimedOut during Run", TcStatusName(result.Status), result.StatusMessage)
+	}
+	if result := runTest(&tm, "suite1", suite.GetTestCase("allowed")); result.Status != TcPassed {
+		t.Errorf("test with a longer timeout param is %s: %s", TcStatusName(result.Status), result.StatusMessage)
+	}
+	if timeout := tm.getTestTimeout("other", &checkTest{}); timeout != time.Hour {
+		t.Errorf("manager timeout is %s, want 1h", timeout)
+	}
+	<-slow.done
+}
+
+// loggingTest keeps logging after it timed out, while the manager runs
+// Teardown() and collects the output and params of the result
+type loggingTest struct {
+	TestCase
+	done chan bool
+}
+
+func (t *loggingTest) Run() (int, error) {
+	defer close(t.done)
+	deadline := time.Now().Add(100 * time.Millisecond)
+	for i := 0; time.Now().Before(deadline); i++ {
+		t.LogPass("check %d", i)
+		t.AddParam(fmt.Sprintf("param%d", i), i, "")
+	}
+	return t.ReturnFromRun()
+}
+
+func (t *loggingTest) Teardown() (int, error) {
+	t.LogMessage("teardown")
+	return TcPassed, nil
+}
+
+// TestTimedOutTestLogging is meant to be run with -race
+func TestTimedOutTestLogging(t *testing.T) {
+	tm := NewManager(ioutil.Discard, nil, SuiteSerial, TcSerial)
+	tm.SetTestTimeout(10 * time.Millisecond)
+	test := &loggingTest{done: make(chan bool)}
+	test.Init("logging", &tm, Parameters{})
+
+	result := runTest(&tm, "", test)
+	if result.Status != TcTimedOut {
+		t.Errorf("logging test is %s: %s, want TimedOut", TcStatusName(result.Status), result.StatusMessage)
+	}
+	if !strings.Contains(result.Output(), "PASS::check 0") {
+		t.Errorf("output of the timed out test is missing: %q", result.Output())
+	}
+	<-test.done
+}
+
+// classSuite is a suite class of DefaultRegister.Suites that counts its setups
+type classSuite struct {
+	DefaultSuite
+	setups int
+}
+
+func (s *classSuite) Setup() (status int, msg string, err error) {
+	s.setups++
+	return SuiteOk, "", nil
+}
+
+func TestRegisterSuiteClass(t *testing.T) {
+	reg := validateRegister()
+	reg.Suites = map[string]reflect.Type{"classSuite": reflect.TypeOf(classSuite{})}
+	plan := parseXMLPlan(t, `
+<TestManager name="Manager">
+  <TestSuite name="suite1" class="classSuite">
+    <Param name="maxTime" type="int">100</Param>
+    <TestCase name="test1" class="counting"/>
+  </TestSuite>
+  <TestSuite name="suite2" class="DefaultSuite">
+    <Param name="maxTime" type="int">200</Param>
+    <TestCase name="test1" class="counting"/>
+  </TestSuite>
+</TestManager>`)
+	tm := newTestManager()
+	if err := tm.ValidateTestPlan(plan, reg); err != nil {
+		t.Errorf("plan with a suite class has errors: %s", err)
+	}
+	if err := tm.AddTestPlan(plan, reg); err != nil {
+		t.Fatal(err)
+	}
+	suite1, ok := tm.GetSuite("suite1").(*classSuite)
+	if !ok {
+		t.Fatalf("suite1 is %T", tm.GetSuite("suite1"))
+	}
+	suite2, ok := tm.GetSuite("suite2").(*DefaultSuite)
+	if !ok {
+		t.Fatalf("suite2 is %T", tm.GetSuite("suite2"))
+	}
+	for _, suite := range []*DefaultSuite{&suite1.DefaultSuite, suite2} {
+		if maxTime, err := suite.GetParams().GetInt("maxTime"); err != nil || maxTime != map[string]int{"suite1": 100, "suite2": 200}[suite.Name()] {
+			t.Errorf("%s has maxTime %d, %v", suite.Name(), maxTime, err)
+		}
+	}
+	tm.RunAll()
+	if suite1.setups != 1 {
+		t.Errorf("Setup() of the suite class ran %d times", suite1.setups)
+	}
+
+	reg.Suites["counting"] = reflect.TypeOf(countingTest{})
+	if reg.HasSuite("counting") || reg.HasSuite("Bogus") || !reg.HasSuite("classSuite") {
+		t.Errorf("HasSuite doesn't match GetSuite")
+	}
+	for class, want := range map[string]string{
+		"Bogus":    "invalid suite class 'Bogus'",
+		"counting": "suite class 'counting' does not implement Suite",
+	} {
+		if _, err := reg.GetSuite("suite1", class, tm, Parameters{}); err == nil || err.Error() != want {
+			t.Errorf("class %s: want error %q, got %v", class, want, err)
+		}
+	}
+}
+
+func TestNewSuiteParams(t *testing.T) {
+	params := Parameters{}
+	params.AddParam("maxTime", 100, "")
+	suite := NewSuite("suite1", newTestManager(), params)
+	if maxTime, err := suite.GetParams().GetInt("maxTime"); err != nil || maxTime != 100 {
+		t.Errorf("suite has maxTime %d, %v", maxTime, err)
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Test plan formats read by ParseTestPlan()
+const (
+	PlanFormatXML  = "xml"
+	PlanFormatJSON = "json"
+	PlanFormatYAML = "yaml"
+)
+
+// PlanFormat returns the test plan format for the extension of fileName.
+// ".json" is JSON, ".yaml" and ".yml" are YAML, and anything else is XML
+func PlanFormat(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".json":
+		return PlanFormatJSON
+	case ".yaml", ".yml":
+		return PlanFormatYAML
+	}
+	return PlanFormatXML
+}
+
+// ParseTestPlan reads a test plan from fileName in the format of its
+// file extension, see PlanFormat(). JSON and YAML plans have the same
+// structure as XML plans, with lower case names and "tests" for test cases:
+//
+//	name: Manager
+//	params:
+//	  - {name: Domain, type: string, value: github.com/go-QA/goQA}
+//	suites:
+//	  - name: suite1
+//	    class: DefaultSuite
+//	    tags: smoke
+//	    params:
+//	      - {name: SuiteMaxTime, type: int, value: 100, comment: Max time for suite}
+//	    tests:
+//	      - name: test1
+//	        class: test1
+//	        dependsOn: test2
+//	        params:
+//	          - {name: val1, value: 11.11}
+//
+// A param value without type is "int", "float", "bool", "string", or "json" for
+// lists and objects, by its JSON or YAML type. Lists and objects can also be
+// used as values for the list[<type>] and map[<type>] param types.
+//
+// The included plans and suite templates are expanded, see ExpandTestPlan()
+func (tm *TestManager) ParseTestPlan(fileName string, testPlan *XMLTestPlan) error {
+	if err := tm.parsePlanFile(fileName, testPlan); err != nil {
+		return err
+	}
+	return tm.ExpandTestPlan(testPlan)
+}
+
+// parsePlanFile reads fileName without expanding it
+func (tm *TestManager) parsePlanFile(fileName string, testPlan *XMLTestPlan) error {
+	// includes and data files of the plan are relative to it
+	testPlan.file = fileName
+	switch PlanFormat(fileName) {
+	case PlanFormatJSON:
+		return tm.ParseTestPlanFromJSON(fileName, testPlan)
+	case PlanFormatYAML:
+		return tm.ParseTestPlanFromYAML(fileName, testPlan)
+	}
+	return tm.ParseTestPlanFromXML(fileName, testPlan)
+}
+
+// ParseTestPlanFromJSON tries to read a JSON test plan from fileName into testPlan
+func (tm *TestManager) ParseTestPlanFromJSON(fileName string, testPlan *XMLTestPlan) error {
+	buf, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+
+	tm.log.LogDebug(string(buf))
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.DisallowUnknownFields()
+	return dec.Decode(testPlan)
+}
+
+// ParseTestPlanFromYAML tries to read a YAML test plan from fileName into testPlan
+func (tm *TestManager) ParseTestPlanFromYAML(fileName string, testPlan *XMLTestPlan) error {
+	buf, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+
+	tm.log.LogDebug(string(buf))
+	dec := yaml.NewDecoder(bytes.NewReader(buf))
+	dec.KnownFields(true)
+	return dec.Decode(testPlan)
+}
+
+// RunFromPlan reads the test plan fileName in the format of its file extension,
+// see ParseTestPlan(), and runs the suites with test cases by calling RunAll().
+// overrides replace params of the plan, see AddParamOverrides()
+func (tm *TestManager) RunFromPlan(fileName string, registry TestRegister, overrides ...map[string]string) error {
+	for _, o := range overrides {
+		if err := tm.AddParamOverrides(o); err != nil {
+			tm.log.LogError("Invalid param overrides::error=%s", err.Error())
+			return err
+		}
+	}
+
+	var testPlan XMLTestPlan
+	err := tm.ParseTestPlan(fileName, &testPlan)
+	if err != nil {
+		tm.log.LogError("Unable to parse %s file %s: error=%s", strings.ToUpper(PlanFormat(fileName)), fileName, err.Error())
+		return err
+	}
+
+	err = tm.AddTestPlan(&testPlan, registry)
+	if planErrors, ok := err.(PlanErrors); ok {
+		planErrors.setFile(fileName)
+		for _, planError := range planErrors {
+			tm.log.LogError("Invalid test plan::%s", planError.Error())
+		}
+		return err
+	} else if err != nil {
+		tm.log.LogError("Unable to add test plan::error=%s", err.Error())
+		return err
+	}
+	tm.RunAll()
+
+	return nil
+}
+
+// planParam is XMLParam with a value of any JSON or YAML type
+type planParam struct {
+	Name    string      `json:"name" yaml:"name"`
+	Type    string      `json:"type" yaml:"type"`
+	Comment string      `json:"comment" yaml:"comment"`
+	GoType  string      `json:"goType" yaml:"goType"`
+	Value   interface{} `json:"value" yaml:"value"`
+}
+
+// set copies p to param with the value as string. A missing type is
+// taken from the value
+func (p *planParam) set(param *XMLParam) error {
+	param.Name = p.Name
+	param.Type = p.Type
+	param.Comment = p.Comment
+	param.GoType = p.GoType
+
+	inferred := "string"
+	switch v := p.Value.(type) {
+	case nil:
+		param.Value = ""
+	case string:
+		param.Value = v
+	case int, int64, uint64:
+		param.Value = fmt.Sprint(v)
+		inferred = "int"
+	case float64:
+		param.Value = fmt.Sprint(v)
+		inferred = "float"
+	case json.Number:
+		param.Value = v.String()
+		inferred = "float"
+		if _, err := v.Int64(); err == nil {
+			inferred = "int"
+		}
+	case bool:
+		param.Value = fmt.Sprint(v)
+		inferred = "bool"
+	case time.Time:
+		param.Value = v.Format(time.RFC3339Nano)
+		inferred = "time"
+	default:
+		// lists and maps are kept as JSON for the list, map, and json types
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("param '%s' value %v (%T) can't be used: %s", p.Name, p.Value, p.Value, err.Error())
+		}
+		param.Value = string(buf)
+		inferred = "json"
+	}
+	if param.Type == "" {
+		param.Type = inferred
+	}
+	return nil
+}
+
+// UnmarshalJSON reads a param with a value of any JSON type
+func (param *XMLParam) UnmarshalJSON(data []byte) error {
+	var p planParam
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return err
+	}
+	return p.set(param)
+}
+
+// UnmarshalYAML reads a param with a value of any YAML type
+func (param *XMLParam) UnmarshalYAML(value *yaml.Node) error {
+	var p planParam
+	if err := value.Decode(&p); err != nil {
+		return err
+	}
+	return p.set(param)
+}
@@ -0,0 +1,143 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPlanFormat(t *testing.T) {
+	for name, want := range map[string]string{
+		"plan.json": PlanFormatJSON,
+		"plan.YAML": PlanFormatYAML,
+		"plan.yml":  PlanFormatYAML,
+		"plan.xml":  PlanFormatXML,
+		"plan":      PlanFormatXML,
+	} {
+		if format := PlanFormat(name); format != want {
+			t.Errorf("format of %s is %s, want %s", name, format, want)
+		}
+	}
+}
+
+func TestParseTestPlanFormats(t *testing.T) {
+	tm := newTestManager()
+	var fromXML, fromYAML XMLTestPlan
+	if err := tm.ParseTestPlan("examples/ExampleTestPlan.xml", &fromXML); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.ParseTestPlan("examples/ExampleTestPlan.yaml", &fromYAML); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromXML.Params, fromYAML.Params) || !reflect.DeepEqual(fromXML.Suites[0], fromYAML.Suites[0]) {
+		t.Errorf("YAML plan:\n%+v\nXML plan:\n%+v", fromYAML.Suites[0], fromXML.Suites[0])
+	}
+
+	// the XML plan written as JSON and YAML reads back the same
+	dir := t.TempDir()
+	buf, err := json.Marshal(&fromXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonFile := filepath.Join(dir, "plan.json")
+	if err := ioutil.WriteFile(jsonFile, buf, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var fromJSON XMLTestPlan
+	if err := tm.ParseTestPlan(jsonFile, &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if fromJSON.Name != fromXML.Name || !reflect.DeepEqual(fromJSON.Params, fromXML.Params) || !reflect.DeepEqual(fromJSON.Suites, fromXML.Suites) {
+		t.Errorf("JSON plan:\n%+v\nXML plan:\n%+v", fromJSON, fromXML)
+	}
+
+	buf, err = xml.Marshal(&fromJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xmlFile := filepath.Join(dir, "plan.xml")
+	if err := ioutil.WriteFile(xmlFile, buf, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var again XMLTestPlan
+	if err := tm.ParseTestPlan(xmlFile, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again.Suites, fromXML.Suites) {
+		t.Errorf("XML plan changed after a round trip:\n%+v", again.Suites)
+	}
+}
+
+func TestParseTestPlanValues(t *testing.T) {
+	dir := t.TempDir()
+	plans := map[string]string{
+		"plan.json": `{"name": "Manager", "suites": [{"name": "suite1", "class": "DefaultSuite", "tests": [
+			{"name": "test1", "class": "test1", "params": [
+				{"name": "count", "value": 3},
+				{"name": "ratio", "value": 1.5},
+				{"name": "enabled", "value": true},
+				{"name": "label", "value": "x"},
+				{"name": "hosts", "value": ["a", "b"]},
+				{"name": "big", "type": "int64", "value": 9007199254740993}]}]}]}`,
+		"plan.yaml": `
+name: Manager
+suites:
+  - name: suite1
+    class: DefaultSuite
+    tests:
+      - name: test1
+        class: test1
+        params:
+          - {name: count, value: 3}
+          - {name: ratio, value: 1.5}
+          - {name: enabled, value: true}
+          - {name: label, value: x}
+          - {name: hosts, value: [a, b]}
+          - {name: big, type: int64, value: 9007199254740993}
+`,
+	}
+	want := []XMLParam{
+		{Name: "count", Type: "int", Value: "3"},
+		{Name: "ratio", Type: "float", Value: "1.5"},
+		{Name: "enabled", Type: "bool", Value: "true"},
+		{Name: "label", Type: "string", Value: "x"},
+		{Name: "hosts", Type: "json", Value: `["a","b"]`},
+		{Name: "big", Type: "int64", Value: "9007199254740993"},
+	}
+	tm := newTestManager()
+	for name, content := range plans {
+		file := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var plan XMLTestPlan
+		if err := tm.ParseTestPlan(file, &plan); err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if params := plan.Suites[0].TestCases[0].Params; !reflect.DeepEqual(params, want) {
+			t.Errorf("%s params:\n%+v\nwant:\n%+v", name, params, want)
+		}
+	}
+
+	for name, content := range map[string]string{
+		"unknown.json": `{"name": "Manager", "suites": [], "bogus": 1}`,
+		"unknown.yaml": "name: Manager\nsuites: []\nbogus: 1\n",
+	} {
+		file := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var plan XMLTestPlan
+		if err := tm.ParseTestPlan(file, &plan); err == nil {
+			t.Errorf("%s: no error for an unknown field", name)
+		}
+	}
+}
@@ -8,9 +8,10 @@ import (
 	"fmt"
 	//"error"
 	//"log"
-	//"os"
-	//"io"
 	"bytes"
+	"io"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -27,6 +28,64 @@ type ReportWriter interface {
 	PerformManagerStatistics(report *ManagerResult, name, msg string, complete chan int)
 }
 
+// Listener interfaces are optional for a ReportWriter that wants events
+// as they happen instead of only in PerformManagerStatistics(). Every
+// ReportWriter added to the TestManager is checked for each of them.
+// Calls are made after ManagerResult is unlocked, so a listener can call back
+// into the manager, but tests running in parallel can call it at the same time
+
+// TestResult and SuiteResult name the results passed to listeners so they
+// can be implemented outside of goQA
+type (
+	TestResult  = testResult
+	SuiteResult = suiteResult
+)
+
+// ManagerListener gets called when the manager starts running suites
+type ManagerListener interface {
+	ManagerStarted(name string)
+}
+
+// SuiteListener gets called when suites start and finish
+type SuiteListener interface {
+	SuiteStarted(suiteName string)
+	SuiteFinished(result suiteResult)
+}
+
+// TestListener gets called when tests start and finish
+type TestListener interface {
+	TestStarted(suiteName, testName string)
+	TestFinished(suiteName string, result testResult)
+}
+
+// CheckpointListener gets called for each check point a TestCase logs.
+// level is ResultPass, ResultFail, ResultWarning, or ResultError
+type CheckpointListener interface {
+	CheckpointLogged(suiteName, testName string, level int, msg string)
+}
+
+// reportOutput is the io.Writer or file name a ReportWriter writes to
+type reportOutput struct {
+	writer   io.Writer
+	fileName string
+}
+
+// open returns the writer for the report and a function to close it.
+// A file name creates (or overwrites) the file.
+func (o *reportOutput) open() (io.Writer, func() error, error) {
+	if o.fileName != "" {
+		f, err := os.Create(o.fileName)
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, f.Close, nil
+	}
+	if o.writer == nil {
+		return nil, nil, fmt.Errorf("no writer or file name given for report")
+	}
+	return o.writer, func() error { return nil }, nil
+}
+
 // Status codes returned for Test Cases
 const (
 	_ = iota
@@ -40,6 +99,8 @@ const (
 	TcSetupError
 	TcTeardownFailed
 	TcTeardownError
+	TcTimedOut
+	TcFlaky
 )
 
 // Status codes retuned for suites
@@ -69,30 +130,108 @@ const (
 	ManagerTeardownError
 )
 
+var tcStatusNames = map[int]string{
+	TcNotFound:       "NotFound",
+	TcSkipped:        "Skipped",
+	TcPassed:         "Passed",
+	TcFailed:         "Failed",
+	TcError:          "Error",
+	TcCriticalError:  "CriticalError",
+	TcSetupFailed:    "SetupFailed",
+	TcSetupError:     "SetupError",
+	TcTeardownFailed: "TeardownFailed",
+	TcTeardownError:  "TeardownError",
+	TcTimedOut:       "TimedOut",
+	TcFlaky:          "Flaky",
+}
+
+// TcStatusFromName returns the Tc* status code for a name returned by
+// TcStatusName(). ok is false if name is unknown
+func TcStatusFromName(name string) (status int, ok bool) {
+	for status, statusName := range tcStatusNames {
+		if strings.EqualFold(statusName, name) {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+var suiteStatusNames = map[int]string{
+	SuiteOk:             "Ok",
+	SuiteNotFound:       "NotFound",
+	SuiteSkipped:        "Skipped",
+	SuitePassed:         "Passed",
+	SuiteFailed:         "Failed",
+	SuiteError:          "Error",
+	SuiteCriticalError:  "CriticalError",
+	SuiteSetupFailed:    "SetupFailed",
+	SuiteSetupError:     "SetupError",
+	SuiteTeardownFailed: "TeardownFailed",
+	SuiteTeardownError:  "TeardownError",
+}
+
+var managerStatusNames = map[int]string{
+	ManagerPassed:         "Passed",
+	ManagerFailed:         "Failed",
+	ManagerSetupFailed:    "SetupFailed",
+	ManagerSetupError:     "SetupError",
+	ManagerTeardownFailed: "TeardownFailed",
+	ManagerTeardownError:  "TeardownError",
+}
+
+// TcStatusName returns the name of a Tc* status code or "Unknown"
+func TcStatusName(status int) string {
+	if name, ok := tcStatusNames[status]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// SuiteStatusName returns the name of a Suite* status code or "Unknown"
+func SuiteStatusName(status int) string {
+	if name, ok := suiteStatusNames[status]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// ManagerStatusName returns the name of a Manager* status code or "Unknown"
+func ManagerStatusName(status int) string {
+	if name, ok := managerStatusNames[status]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
 // Text Formating for TextReporter
 const (
-	TestPassedReport           = "TEST PASSED          %s (%.2f sec) %s"
-	TestFailedReport           = "TEST FAILED          %s (%.2f sec) %s"
-	TestErrorReport            = "TEST ERROR           %s (%.2f sec) %s"
-	TestSetupFailedReport      = "TEST SETUP FAILED    %s %s"
-	TestSetupErrorReport       = "TEST SETUP ERROR     %s %s"
-	TestTeardownErrorReport    = "TEST TEARDOWN ERROR  %s %s"
-	TestNotFondReport          = "TEST NOT FOUND       %s"
-	TestSkippedReport          = "TEST SKIPPED         %s"
-	SuiteStartedReport         = "SUITE STARTED        %s"
-	SuitePassedReport          = "SUITE PASSED         %s (%.2f sec)"
-	SuiteFailedReport          = "SUITE FAILED         %s (%.2f sec) %s"
-	SuiteErrorReport           = "SUITE ERROR          %s (%.2f sec) %s"
-	SuiteSetupFailedReport     = "SUITE SETUP FAILED   %s %s"
-	SuiteSetupErrorReport      = "SUITE SETUP ERROR    %s %s"
-	SuiteTeardownErrorReport   = "SUITE TEARDOWN ERROR %s %s"
-	SuiteNotFoundReport        = "SUITE NOT FOUND      %s"
-	ManagerStartedReport       = "MNGR STARTED         %s"
-	ManagerSetupFailedReport   = "MNGR SETUP FAILED    %s %s"
-	ManagerSetupErrorReport    = "MNGR SETUP ERROR     %s %s"
-	ManagerTeardownErrorReport = "MNGR TEARDOWN ERROR  %s %s"
-	SuiteStatisticsReport      = "SUITE STATISTICS     %s (%.2f sec)\n\nTests: Total %3d, Passed %3d, Failed %3d, Error %3d, SetUp failed %3d, SetUp error %3d, Not Found %3d"
-	ManagerStatisticsReport    = "TOTAL STATISTICS     %s (%.2f sec)\n\nSuites: Total %3d, Passed %3d, Failed %3d, Error %3d, SetUp failed %3d, SetUp error %3d, Not Found %3d\n Tests: Total %3d, Passed %3d, Failed %3d, Error %3d, SetUp failed %3d, SetUp error %3d, Not Found %3d"
+	TestPassedReport            = "TEST PASSED          %s (%.2f sec) %s"
+	TestFailedReport            = "TEST FAILED          %s (%.2f sec) %s"
+	TestErrorReport             = "TEST ERROR           %s (%.2f sec) %s"
+	TestSetupFailedReport       = "TEST SETUP FAILED    %s %s"
+	TestSetupErrorReport        = "TEST SETUP ERROR     %s %s"
+	TestTeardownErrorReport     = "TEST TEARDOWN ERROR  %s %s"
+	TestTimedOutReport          = "TEST TIMED OUT       %s (%.2f sec) %s"
+	TestFlakyReport             = "TEST FLAKY           %s (%.2f sec) %s"
+	TestNotFondReport           = "TEST NOT FOUND       %s"
+	TestSkippedReport           = "TEST SKIPPED         %s::%s"
+	SuiteStartedReport          = "SUITE STARTED        %s"
+	SuitePassedReport           = "SUITE PASSED         %s (%.2f sec)"
+	SuiteFailedReport           = "SUITE FAILED         %s (%.2f sec) %s"
+	SuiteErrorReport            = "SUITE ERROR          %s (%.2f sec) %s"
+	SuiteSetupFailedReport      = "SUITE SETUP FAILED   %s %s"
+	SuiteSetupErrorReport       = "SUITE SETUP ERROR    %s %s"
+	SuiteTeardownErrorReport    = "SUITE TEARDOWN ERROR %s %s"
+	SuiteNotFoundReport         = "SUITE NOT FOUND      %s"
+	ManagerStartedReport        = "MNGR STARTED         %s"
+	ManagerSetupFailedReport    = "MNGR SETUP FAILED    %s %s"
+	ManagerSetupErrorReport     = "MNGR SETUP ERROR     %s %s"
+	ManagerTeardownErrorReport  = "MNGR TEARDOWN ERROR  %s %s"
+	ManagerTeardownFailedReport = "MNGR TEARDOWN FAILED %s %s"
+	TestAttemptsReport          = " (%d attempts)"
+	TestParamReport             = "    PARAM            %s = %v from %s"
+	SuiteStatisticsReport       = "SUITE STATISTICS     %s (%.2f sec)\n\nTests: Total %3d, Passed %3d, Failed %3d, Error %3d, SetUp failed %3d, SetUp error %3d, Not Found %3d, Timed out %3d, Flaky %3d"
+	ManagerStatisticsReport     = "TOTAL STATISTICS     %s (%.2f sec)\n\nSuites: Total %3d, Passed %3d, Failed %3d, Error %3d, SetUp failed %3d, SetUp error %3d, Not Found %3d\n Tests: Total %3d, Passed %3d, Failed %3d, Error %3d, SetUp failed %3d, SetUp error %3d, Not Found %3d, Timed out %3d, Flaky %3d"
 )
 
 type ReporterStatistics struct {
@@ -119,6 +258,8 @@ type ReporterStatistics struct {
 	TotalNumberOfTestCasesTearDownError  int
 	TotalNumberOfTestCasesNotFound       int
 	TotalNumberOfTestCasesSkipped        int
+	TotalNumberOfTestCasesTimedOut       int
+	TotalNumberOfTestCasesFlaky          int
 }
 
 func (s *ReporterStatistics) Init() {
@@ -146,6 +287,8 @@ func (s *ReporterStatistics) resetManagerResultStatistics() {
 	s.TotalNumberOfTestCasesTearDownError = 0
 	s.TotalNumberOfTestCasesNotFound = 0
 	s.TotalNumberOfTestCasesSkipped = 0
+	s.TotalNumberOfTestCasesTimedOut = 0
+	s.TotalNumberOfTestCasesFlaky = 0
 }
 
 type suiteResult struct {
@@ -168,6 +311,8 @@ type suiteResult struct {
 	NumberOfTestCasesTearDownFailed int
 	NumberOfTestCasesNotFound       int
 	NumberOfTestCasesSkipped        int
+	NumberOfTestCasesTimedOut       int
+	NumberOfTestCasesFlaky          int
 }
 
 func (s *suiteResult) Init(name string) {
@@ -200,9 +345,22 @@ func (s *suiteResult) EndTest(name string, status int, message string) {
 	test.Status = status
 	test.StatusMessage = message
 	test.end = time.Now()
+	delete(s.tempTests, name)
 	s.AddTestResult(test)
 }
 
+// endTestResult finishes the started test with the details in result.
+// Start time is kept from StartTest() when the test was started
+func (s *suiteResult) endTestResult(result testResult) testResult {
+	if test, ok := s.tempTests[result.name]; ok {
+		result.start = test.start
+		delete(s.tempTests, result.name)
+	}
+	result.end = time.Now()
+	s.AddTestResult(result)
+	return result
+}
+
 func (s *suiteResult) AddTestResult(result testResult) {
 	if len(s.tests) >= cap(s.tests) {
 		newSlice := make([]testResult, len(s.tests), len(s.tests)+INITIAL_REPORT_SIZE)
@@ -219,6 +377,9 @@ type testResult struct {
 	StatusMessage string
 	start         time.Time
 	end           time.Time
+	output        string
+	params        Parameters
+	attempts      []testResult // earlier attempts when the test was retried
 }
 
 func (t *testResult) Init(name string) {
@@ -234,14 +395,40 @@ func (t *testResult) Name() string {
 	return t.name
 }
 
+// Output returns the log output captured while the test ran
+func (t *testResult) Output() string {
+	return t.output
+}
+
+// Attempts returns the number of times the test ran
+func (t *testResult) Attempts() int {
+	return len(t.attempts) + 1
+}
+
+// PreviousAttempts returns the results of the attempts before the last one
+// when the test was retried
+func (t *testResult) PreviousAttempts() []testResult {
+	return t.attempts
+}
+
+// Params returns the parameters the test ran with
+func (t *testResult) Params() *Parameters {
+	return &t.params
+}
+
 type ManagerResult struct {
 	mutex          sync.Mutex
 	name           string
+	Status         int
+	StatusMessage  string
 	start          time.Time
 	end            time.Time
 	activeSuites   map[string]suiteResult
 	finishedSuites []suiteResult
 	reportStats    ReporterStatistics
+	generators     map[string]ReportWriter // notified of results as they happen
+	events         []func()                // listener calls queued by notify
+	log            *logger.GoQALog
 }
 
 func (m *ManagerResult) GetSuites() []suiteResult {
@@ -276,9 +463,47 @@ func (m *ManagerResult) EndSuite(name string, status int, message string) {
 	suite.StatusMessage = message
 	suite.end = time.Now()
 	m.AddSuiteResult(suite)
+	m.notify(func(gen ReportWriter) {
+		if l, ok := gen.(SuiteListener); ok {
+			l.SuiteFinished(suite)
+		}
+	})
+}
+
+// notify queues fn for every report generator. Caller must hold m.mutex,
+// fn is called by unlock after the mutex is released
+func (m *ManagerResult) notify(fn func(gen ReportWriter)) {
+	for _, gen := range m.generators {
+		gen := gen
+		m.events = append(m.events, func() { m.notifyGenerator(gen, fn) })
+	}
+}
+
+// unlock releases m.mutex and then calls the listeners queued by notify, so
+// a listener can call back into the manager without deadlocking
+func (m *ManagerResult) unlock() {
+	events := m.events
+	m.events = nil
+	m.mutex.Unlock()
+	for _, event := range events {
+		event()
+	}
+}
+
+// notifyGenerator calls fn for gen. A panic in the generator is logged
+// and does not stop the run
+func (m *ManagerResult) notifyGenerator(gen ReportWriter, fn func(gen ReportWriter)) {
+	defer func() {
+		if r := recover(); r != nil && m.log != nil {
+			m.log.LogError("Report generator '%s' failed::error=%s", gen.Name(), r)
+		}
+	}()
+	fn(gen)
 }
 
 func (m *ManagerResult) EndManager(name string, status int, message string) {
+	m.Status = status
+	m.StatusMessage = message
 	m.end = time.Now()
 }
 
@@ -300,8 +525,13 @@ func (m *ManagerResult) AddSuiteResult(result suiteResult) {
 
 func (m *ManagerResult) EndTest(suiteName string, result testResult) {
 	suite := m.activeSuites[suiteName]
-	suite.EndTest(result.name, result.Status, result.StatusMessage)
+	result = suite.endTestResult(result)
 	m.activeSuites[suiteName] = suite
+	m.notify(func(gen ReportWriter) {
+		if l, ok := gen.(TestListener); ok {
+			l.TestFinished(suiteName, result)
+		}
+	})
 }
 
 func (m *ManagerResult) StartTest(suiteName string, name string) {
@@ -312,13 +542,28 @@ func (m *ManagerResult) StartTest(suiteName string, name string) {
 
 func (m *ManagerResult) testStarted(suiteName string, name string) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	m.StartTest(suiteName, name)
+	m.notify(func(gen ReportWriter) {
+		if l, ok := gen.(TestListener); ok {
+			l.TestStarted(suiteName, name)
+		}
+	})
+}
+
+func (m *ManagerResult) checkpointLogged(suiteName, testName string, level int, msg string) {
+	m.mutex.Lock()
+	defer m.unlock()
+	m.notify(func(gen ReportWriter) {
+		if l, ok := gen.(CheckpointListener); ok {
+			l.CheckpointLogged(suiteName, testName, level, msg)
+		}
+	})
 }
 
 func (m *ManagerResult) testPassed(suiteName string, result testResult) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	s := m.activeSuites[suiteName]
 	s.NumberOfTestCases++
 	s.NumberOfTestCasesPassed++
@@ -330,7 +575,7 @@ func (m *ManagerResult) testPassed(suiteName string, result testResult) {
 
 func (m *ManagerResult) testError(suiteName string, result testResult) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	s := m.activeSuites[suiteName]
 	s.NumberOfTestCases++
 	s.NumberOfTestCasesError++
@@ -342,7 +587,7 @@ func (m *ManagerResult) testError(suiteName string, result testResult) {
 
 func (m *ManagerResult) testFailed(suiteName string, result testResult) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	s := m.activeSuites[suiteName]
 	s.NumberOfTestCases++
 	s.NumberOfTestCasesFailed++
@@ -355,7 +600,7 @@ func (m *ManagerResult) testFailed(suiteName string, result testResult) {
 
 func (m *ManagerResult) testNotFound(suiteName string, result testResult) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	s := m.activeSuites[suiteName]
 	s.NumberOfTestCases++
 	s.NumberOfTestCasesNotFound++
@@ -367,7 +612,7 @@ func (m *ManagerResult) testNotFound(suiteName string, result testResult) {
 
 func (m *ManagerResult) testSkipped(suiteName string, result testResult) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	s := m.activeSuites[suiteName]
 	s.NumberOfTestCases++
 	s.NumberOfTestCasesSkipped++
@@ -379,7 +624,7 @@ func (m *ManagerResult) testSkipped(suiteName string, result testResult) {
 
 func (m *ManagerResult) testSetupFailed(suiteName string, result testResult) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	s := m.activeSuites[suiteName]
 	s.NumberOfTestCases++
 	s.NumberOfTestCasesSetUpFailed++
@@ -391,7 +636,7 @@ func (m *ManagerResult) testSetupFailed(suiteName string, result testResult) {
 
 func (m *ManagerResult) testSetupError(suiteName string, result testResult) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	s := m.activeSuites[suiteName]
 	s.NumberOfTestCases++
 	s.NumberOfTestCasesSetUpFailed++
@@ -401,10 +646,34 @@ func (m *ManagerResult) testSetupError(suiteName string, result testResult) {
 	m.EndTest(suiteName, result)
 }
 
-func (m *ManagerResult) testTeardownFailed(suiteName string, result testResult) {
+func (m *ManagerResult) testTimedOut(suiteName string, result testResult) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	s := m.activeSuites[suiteName]
+	s.NumberOfTestCases++
+	s.NumberOfTestCasesTimedOut++
+	m.reportStats.TotalNumberOfTestCases++
+	m.reportStats.TotalNumberOfTestCasesTimedOut++
+	m.activeSuites[suiteName] = s
+	m.EndTest(suiteName, result)
+}
+
+func (m *ManagerResult) testFlaky(suiteName string, result testResult) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	s := m.activeSuites[suiteName]
+	s.NumberOfTestCases++
+	s.NumberOfTestCasesFlaky++
+	m.reportStats.TotalNumberOfTestCases++
+	m.reportStats.TotalNumberOfTestCasesFlaky++
+	m.activeSuites[suiteName] = s
+	m.EndTest(suiteName, result)
+}
+
+func (m *ManagerResult) testTeardownFailed(suiteName string, result testResult) {
+	m.mutex.Lock()
+	defer m.unlock()
+	s := m.activeSuites[suiteName]
 	s.NumberOfTestCasesTearDownFailed++
 	m.reportStats.TotalNumberOfTestCasesTearDownFailed++
 	m.activeSuites[suiteName] = s
@@ -413,7 +682,7 @@ func (m *ManagerResult) testTeardownFailed(suiteName string, result testResult)
 
 func (m *ManagerResult) testTeardownError(suiteName string, result testResult) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	s := m.activeSuites[suiteName]
 	s.NumberOfTestCasesTearDownError++
 	m.reportStats.TotalNumberOfTestCasesTearDownError++
@@ -423,7 +692,7 @@ func (m *ManagerResult) testTeardownError(suiteName string, result testResult) {
 
 func (m *ManagerResult) suitePassed(suiteName, msg string) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	m.reportStats.NumberOfTestSuites++
 	m.reportStats.NumberOfTestSuitesPassed++
 	m.EndSuite(suiteName, SuitePassed, msg)
@@ -431,15 +700,20 @@ func (m *ManagerResult) suitePassed(suiteName, msg string) {
 
 func (m *ManagerResult) suiteStarted(suiteName, msg string) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	s := suiteResult{}
 	m.activeSuites[suiteName] = s
 	m.StartSuite(suiteName)
+	m.notify(func(gen ReportWriter) {
+		if l, ok := gen.(SuiteListener); ok {
+			l.SuiteStarted(suiteName)
+		}
+	})
 }
 
 func (m *ManagerResult) suiteFailed(suiteName, msg string) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	m.reportStats.NumberOfTestSuites++
 	m.reportStats.NumberOfTestSuitesError++
 	m.EndSuite(suiteName, SuiteFailed, msg)
@@ -447,7 +721,7 @@ func (m *ManagerResult) suiteFailed(suiteName, msg string) {
 
 func (m *ManagerResult) suiteNotFound(suiteName, msg string) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	m.reportStats.NumberOfTestSuites++
 	m.reportStats.NumberOfTestSuitesNotFound++
 	m.EndSuite(suiteName, SuiteNotFound, msg)
@@ -455,35 +729,34 @@ func (m *ManagerResult) suiteNotFound(suiteName, msg string) {
 
 func (m *ManagerResult) suiteSkipped(suiteName, msg string) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
-	m.EndSuite(suiteName, SuiteSkipped, msg)
+	defer m.unlock()
 	m.EndSuite(suiteName, SuiteSkipped, msg)
 }
 
 func (m *ManagerResult) suiteSetupFailed(suiteName, msg string) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	m.reportStats.NumberOfTestSuites++
 	m.reportStats.NumberOfTestSuitesSetUpFailed++
 }
 
 func (m *ManagerResult) suiteSetupError(suiteName, msg string) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	m.reportStats.NumberOfTestSuites++
 	m.reportStats.NumberOfTestSuitesSetUpError++
 }
 
 func (m *ManagerResult) suiteTeardownFailed(suiteName, msg string) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	m.EndSuite(suiteName, SuiteTeardownFailed, msg)
 	m.reportStats.NumberOfTestSuitesTearDownFailed++
 }
 
 func (m *ManagerResult) suiteTeardownError(suiteName, msg string) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	defer m.unlock()
 	m.EndSuite(suiteName, SuiteTeardownError, msg)
 	m.reportStats.NumberOfTestSuitesTearDownError++
 }
@@ -501,16 +774,31 @@ func (m *ManagerResult) managerFailed(name, msg string) {
 }
 
 func (m *ManagerResult) managerStarted(name string) {
+	m.mutex.Lock()
+	defer m.unlock()
 	m.reportStats.Init()
 	m.Init(name)
+	m.notify(func(gen ReportWriter) {
+		if l, ok := gen.(ManagerListener); ok {
+			l.ManagerStarted(name)
+		}
+	})
 }
 
 func (m *ManagerResult) managerSetUpFailed(name, msg string) {
-	m.managerFinished(name, SuiteSetupFailed, msg)
+	m.managerFinished(name, ManagerSetupFailed, msg)
+}
+
+func (m *ManagerResult) managerSetUpError(name, msg string) {
+	m.managerFinished(name, ManagerSetupError, msg)
 }
 
 func (m *ManagerResult) managerTearDownFailed(name, msg string) {
-	m.managerFinished(name, SuiteTeardownFailed, msg)
+	m.managerFinished(name, ManagerTeardownFailed, msg)
+}
+
+func (m *ManagerResult) managerTearDownError(name, msg string) {
+	m.managerFinished(name, ManagerTeardownError, msg)
 }
 
 type TextReporter struct {
@@ -519,6 +807,10 @@ type TextReporter struct {
 	parent Manager
 	stats  ReporterStatistics
 	report ManagerResult
+
+	// ShowParams adds the effective params of each test, with where they
+	// were set, under the test result
+	ShowParams bool
 }
 
 func (t *TextReporter) Name() string {
@@ -556,7 +848,19 @@ func (t *TextReporter) PerformManagerStatistics(report *ManagerResult, name, msg
 		report.reportStats.NumberOfTestSuitesNotFound,
 		report.reportStats.TotalNumberOfTestCases, report.reportStats.TotalNumberOfTestCasesPassed, report.reportStats.TotalNumberOfTestCasesFailed,
 		report.reportStats.TotalNumberOfTestCasesError, report.reportStats.TotalNumberOfTestCasesSetUpFailed,
-		report.reportStats.TotalNumberOfTestCasesSetUpError, report.reportStats.TotalNumberOfTestCasesNotFound)
+		report.reportStats.TotalNumberOfTestCasesSetUpError, report.reportStats.TotalNumberOfTestCasesNotFound,
+		report.reportStats.TotalNumberOfTestCasesTimedOut, report.reportStats.TotalNumberOfTestCasesFlaky)
+
+	switch report.Status {
+	case ManagerSetupFailed:
+		fmt.Fprintf(&rep, "\n"+ManagerSetupFailedReport, name, report.StatusMessage)
+	case ManagerSetupError:
+		fmt.Fprintf(&rep, "\n"+ManagerSetupErrorReport, name, report.StatusMessage)
+	case ManagerTeardownFailed:
+		fmt.Fprintf(&rep, "\n"+ManagerTeardownFailedReport, name, report.StatusMessage)
+	case ManagerTeardownError:
+		fmt.Fprintf(&rep, "\n"+ManagerTeardownErrorReport, name, report.StatusMessage)
+	}
 
 	fmt.Fprintf(&rep, "\n\n\n")
 	fmt.Fprintf(&rep, "            Suite Summary:\n")
@@ -565,7 +869,8 @@ func (t *TextReporter) PerformManagerStatistics(report *ManagerResult, name, msg
 		fmt.Fprintf(&rep, SuiteStatisticsReport, suite.name, suite.end.Sub(suite.start).Seconds(), suite.NumberOfTestCases,
 			suite.NumberOfTestCasesPassed, suite.NumberOfTestCasesFailed,
 			suite.NumberOfTestCasesError, suite.NumberOfTestCasesSetUpFailed,
-			suite.NumberOfTestCasesSetUpError, suite.NumberOfTestCasesNotFound)
+			suite.NumberOfTestCasesSetUpError, suite.NumberOfTestCasesNotFound,
+			suite.NumberOfTestCasesTimedOut, suite.NumberOfTestCasesFlaky)
 
 		fmt.Fprintf(&rep, "\n")
 		switch suite.Status {
@@ -600,10 +905,23 @@ func (t *TextReporter) PerformManagerStatistics(report *ManagerResult, name, msg
 			case TcTeardownFailed, TcTeardownError:
 				fmt.Fprintf(&rep, TestTeardownErrorReport, test.name, test.StatusMessage)
 			case TcSkipped:
-				fmt.Fprintf(&rep, TestSkippedReport, test.name)
+				fmt.Fprintf(&rep, TestSkippedReport, test.name, test.StatusMessage)
+			case TcTimedOut:
+				fmt.Fprintf(&rep, TestTimedOutReport, test.name, test.end.Sub(test.start).Seconds(), test.StatusMessage)
+			case TcFlaky:
+				fmt.Fprintf(&rep, TestFlakyReport, test.name, test.end.Sub(test.start).Seconds(), test.StatusMessage)
 
 			}
+			if test.Attempts() > 1 {
+				fmt.Fprintf(&rep, TestAttemptsReport, test.Attempts())
+			}
 			fmt.Fprintf(&rep, "\n")
+			if t.ShowParams {
+				for _, paramName := range test.params.Names() {
+					param, _ := test.params.GetParam(paramName)
+					fmt.Fprintf(&rep, TestParamReport+"\n", param.Name(), param.Value(), param.Describe())
+				}
+			}
 		}
 		fmt.Fprintf(&rep, "\n\n")
 		fmt.Fprintf(&rep, "-----------------------------------------------------------------------\n\n")
@@ -0,0 +1,21 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import "testing"
+
+func TestStatusNames(t *testing.T) {
+	for status, name := range tcStatusNames {
+		if got := TcStatusName(status); got != name {
+			t.Errorf("TcStatusName(%d) = %q, want %q", status, got, name)
+		}
+	}
+	if TcStatusName(0) != "Unknown" || SuiteStatusName(0) != "Unknown" || ManagerStatusName(0) != "Unknown" {
+		t.Errorf("unknown status codes aren't named Unknown")
+	}
+	if SuiteStatusName(SuiteSetupError) != "SetupError" || ManagerStatusName(ManagerTeardownFailed) != "TeardownFailed" {
+		t.Errorf("wrong suite or manager status names")
+	}
+}
@@ -5,7 +5,9 @@
 package goQA
 
 import (
+	"context"
 	"fmt"
+	"time"
 )
 
 type Suite interface {
@@ -18,9 +20,60 @@ type Suite interface {
 	GetTestCases() []Tester
 }
 
+// ContextSuite is a Suite with Setup and Teardown methods that get a
+// context.Context, which is cancelled when the run is cancelled
+type ContextSuite interface {
+	Suite
+	SetupContext(ctx context.Context) (status int, msg string, err error)
+	TeardownContext(ctx context.Context) (status int, msg string, err error)
+}
+
+type contextSuiteSetupper interface {
+	SetupContext(ctx context.Context) (status int, msg string, err error)
+}
+
+type contextSuiteTeardowner interface {
+	TeardownContext(ctx context.Context) (status int, msg string, err error)
+}
+
+// NewContextSuite returns suite as a ContextSuite. When suite doesn't have
+// the context methods, they call Setup() or Teardown()
+func NewContextSuite(suite Suite) ContextSuite {
+	if cs, ok := suite.(ContextSuite); ok {
+		return cs
+	}
+	return &contextSuite{suite}
+}
+
+// contextSuite adapts a Suite to ContextSuite
+type contextSuite struct {
+	Suite
+}
+
+func (s *contextSuite) SetupContext(ctx context.Context) (status int, msg string, err error) {
+	if c, ok := s.Suite.(contextSuiteSetupper); ok {
+		return c.SetupContext(ctx)
+	}
+	return s.Suite.Setup()
+}
+
+func (s *contextSuite) TeardownContext(ctx context.Context) (status int, msg string, err error) {
+	if c, ok := s.Suite.(contextSuiteTeardowner); ok {
+		return c.TeardownContext(ctx)
+	}
+	return s.Suite.Teardown()
+}
+
 type DefaultSuite struct {
 	TestCase
-	testCases []Tester
+	testCases   []Tester
+	testTimeout time.Duration
+	retryPolicy RetryPolicy
+
+	dependencies map[string][]string // test name to names of tests it depends on
+	tags         []string
+	testTags     map[string][]string // test name to tags of the test
+	matrixTests  map[string][]string // matrix test name to names of the tests generated from it
 }
 
 func (s *DefaultSuite) GetParent() Manager {
@@ -28,8 +81,11 @@ func (s *DefaultSuite) GetParent() Manager {
 }
 
 func (s *DefaultSuite) Init(name string, parent Manager, params Parameters) {
-	s.TestCase.Init(name, parent, Parameters{})
+	s.TestCase.Init(name, parent, params)
 	s.testCases = []Tester{}
+	s.dependencies = map[string][]string{}
+	s.testTags = map[string][]string{}
+	s.matrixTests = map[string][]string{}
 }
 
 func (s *DefaultSuite) Name() string {
@@ -62,6 +118,17 @@ func (s *DefaultSuite) RunSuite() {
 	s.GetParent().RunSuite(s.Name())
 }
 
+// SetTestTimeout overrides the manager test timeout for tests in the suite.
+// 0 uses the manager test timeout
+func (s *DefaultSuite) SetTestTimeout(timeout time.Duration) {
+	s.testTimeout = timeout
+}
+
+// TestTimeout returns the test timeout set by SetTestTimeout()
+func (s *DefaultSuite) TestTimeout() time.Duration {
+	return s.testTimeout
+}
+
 func (s *DefaultSuite) GetTestCase(name string) Tester {
 	for _, test := range s.testCases {
 		if test.Name() == name {
@@ -78,6 +145,6 @@ func (s *DefaultSuite) GetTestCases() []Tester {
 // CreateSuite the default suite object that has basic functionality to run a suite.
 func NewSuite(name string, parent Manager, params Parameters) *DefaultSuite {
 	suite := DefaultSuite{}
-	suite.Init(name, parent, Parameters{})
+	suite.Init(name, parent, params)
 	return &suite
 }
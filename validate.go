@@ -0,0 +1,278 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlanError is a problem found in a test plan by ValidateTestPlan().
+// Location is XPath style, like
+//
+//	/TestManager/TestSuite[@name='suite1']/TestCase[@name='test1']/Param[@name='val2']
+//
+// and is used for XML, JSON, and YAML plans
+type PlanError struct {
+	File     string // plan file, if known. Suites from included plans have their own
+	Location string
+	Message  string
+}
+
+func (e PlanError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s:%s: %s", e.File, e.Location, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Location, e.Message)
+}
+
+// PlanErrors is all the problems found in a test plan
+type PlanErrors []PlanError
+
+func (e PlanErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, planError := range e {
+		lines = append(lines, planError.Error())
+	}
+	return fmt.Sprintf("test plan has %d error(s):\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// setFile sets File of the errors that aren't from an included plan to fileName
+func (e PlanErrors) setFile(fileName string) {
+	for i := range e {
+		if e[i].File == "" {
+			e[i].File = fileName
+		}
+	}
+}
+
+// ClassChecker is implemented by a TestRegister, like DefaultRegister, that
+// can tell ValidateTestPlan() if a class is registered without creating it.
+// Class names are not checked for registries without it
+type ClassChecker interface {
+	HasTestCase(testCaseClass string) bool
+	HasSuite(suiteClass string) bool
+	HasHook(hookClass string) bool
+}
+
+// planValidator collects the problems of a test plan
+type planValidator struct {
+	tm        *TestManager
+	plan      *XMLTestPlan
+	classes   ClassChecker // nil if the registry can't check class names
+	overrides []paramOverride
+	errors    PlanErrors
+	file      string // plan file of the suite being checked, when it is from an include
+}
+
+// addError adds a problem once, the tests generated from a test case can
+// have the same one
+func (v *planValidator) addError(location, format string, args ...interface{}) {
+	planError := PlanError{File: v.file, Location: location, Message: fmt.Sprintf(format, args...)}
+	for _, e := range v.errors {
+		if e == planError {
+			return
+		}
+	}
+	v.errors = append(v.errors, planError)
+}
+
+func planLocation(parent, element, name string) string {
+	return fmt.Sprintf("%s/%s[@name='%s']", parent, element, name)
+}
+
+// nthLocation is planLocation() with the position of the element among
+// elements with the same name, when there is more than one
+func nthLocation(parent, element, name string, seen map[string]int) string {
+	seen[name]++
+	if seen[name] > 1 {
+		return fmt.Sprintf("%s[%d]", planLocation(parent, element, name), seen[name])
+	}
+	return planLocation(parent, element, name)
+}
+
+// ValidateTestPlan checks testPlan before it is added by AddTestPlan() and
+// returns PlanErrors with every problem found, or nil if the plan is valid.
+// It checks for unknown test, suite, and hook classes, unknown param types,
+// values that can't be converted to their type, unresolved ${...}
+// references and reference cycles, duplicate suite or test names, empty
+// suites, matrices without values, unreadable data files, and dependencies
+// on unknown tests. Nothing is created from the registry, so no Init() of
+// suites, tests, or hooks is called
+func (tm *TestManager) ValidateTestPlan(testPlan *XMLTestPlan, registry TestRegister) error {
+	v := &planValidator{tm: tm, plan: testPlan}
+	v.classes, _ = registry.(ClassChecker)
+	root := "/TestManager"
+	overrides, err := tm.paramOverrides()
+	if err != nil {
+		v.addError(root, "%s", err.Error())
+	}
+	v.overrides = overrides
+
+	mngrParams := v.validateParams(root, testPlan.Params, ParamSource{Kind: ParamFromManager}, nil, "")
+	for i, xmlHook := range testPlan.Hooks {
+		location := fmt.Sprintf("%s/Hook[%d]", root, i+1)
+		if _, ok := registry.(HookRegister); !ok {
+			v.addError(location, "registry does not support manager hooks")
+		} else if v.classes != nil && !v.classes.HasHook(strings.TrimSpace(xmlHook.Class)) {
+			v.addError(location, "unknown hook class '%s'", xmlHook.Class)
+		}
+		v.validateParams(location, xmlHook.Params, ParamSource{Kind: ParamFromHook}, mngrParams, "")
+	}
+
+	suiteNames := map[string]bool{}
+	for _, suite := range tm.suites {
+		suiteNames[suite.Name()] = true
+	}
+	seen := map[string]int{}
+	for _, xmlSuite := range testPlan.Suites {
+		var location string
+		v.file, location = xmlSuite.source(seen)
+		v.validateSuite(location, xmlSuite, suiteNames, mngrParams)
+	}
+	v.file = ""
+
+	if len(v.errors) > 0 {
+		return v.errors
+	}
+	return nil
+}
+
+func (v *planValidator) validateSuite(location string, xmlSuite XMLTestSuite, suiteNames map[string]bool, mngrParams *Parameters) {
+	switch {
+	case xmlSuite.Name == "":
+		v.addError(location, "suite has no name")
+	case suiteNames[xmlSuite.Name]:
+		v.addError(location, "duplicate suite name '%s'", xmlSuite.Name)
+	}
+	suiteNames[xmlSuite.Name] = true
+
+	suiteParams := v.validateParams(location, xmlSuite.Params, ParamSource{Kind: ParamFromSuite}, mngrParams, xmlSuite.Name)
+	if v.classes != nil && !v.classes.HasSuite(xmlSuite.Class) {
+		v.addError(location, "unknown suite class '%s'", xmlSuite.Class)
+	}
+
+	if len(xmlSuite.TestCases) == 0 {
+		v.addError(location, "suite has no test cases")
+	}
+
+	testNames := map[string]bool{}
+	seen := map[string]int{}
+	for _, xmlTest := range xmlSuite.TestCases {
+		testLocation := nthLocation(location, "TestCase", xmlTest.Name, seen)
+		switch {
+		case xmlTest.Name == "":
+			v.addError(testLocation, "test case has no name")
+		case testNames[xmlTest.Name]:
+			v.addError(testLocation, "duplicate test name '%s'", xmlTest.Name)
+		}
+		testNames[xmlTest.Name] = true
+
+		if v.classes != nil && !v.classes.HasTestCase(xmlTest.Class) {
+			v.addError(testLocation, "unknown test class '%s'", xmlTest.Class)
+		}
+		if xmlTest.Matrix == nil && xmlTest.Data == nil {
+			v.validateParams(testLocation, xmlTest.Params, ParamSource{Kind: ParamFromTest}, suiteParams, xmlSuite.Name, xmlTest.Name)
+			continue
+		}
+		if m, ok := v.classes.(matrixChecker); ok && v.classes.HasSuite(xmlSuite.Class) && !m.hasMatrixSuite(xmlSuite.Class) {
+			v.addError(testLocation, "suite does not support matrix or data tests")
+		}
+		v.validateGenerated(testLocation, xmlSuite.Name, xmlTest, testNames, suiteParams)
+	}
+
+	seen = map[string]int{}
+	for _, xmlTest := range xmlSuite.TestCases {
+		testLocation := nthLocation(location, "TestCase", xmlTest.Name, seen)
+		for _, name := range splitList(xmlTest.DependsOn) {
+			if !testNames[name] {
+				v.addError(testLocation+"/@dependsOn",
+					"depends on unknown test '%s'", name)
+			}
+		}
+	}
+}
+
+// validateGenerated checks the matrix or data file of xmlTest and the params
+// of each test generated from it, and adds the generated names to testNames
+func (v *planValidator) validateGenerated(location, suiteName string, xmlTest XMLTestCase,
+	testNames map[string]bool, suiteParams *Parameters) {
+	generated, errs := v.plan.generatedTests(xmlTest, location)
+	for _, e := range errs {
+		v.addError(e.Location, "%s", e.Message)
+	}
+
+	// a problem of the params is reported once, for the first test that has it
+	reported := map[PlanError]bool{}
+	for _, g := range generated {
+		if testNames[g.name] {
+			v.addError(location, "duplicate test name '%s'", g.name)
+		}
+		testNames[g.name] = true
+
+		for _, value := range g.values {
+			for _, param := range xmlTest.Params {
+				if param.Name != value.Name {
+					continue
+				}
+				if g.source.Kind == ParamFromData {
+					v.addError(planLocation(location, "Param", param.Name), "param is also a column of the data file")
+				} else {
+					v.addError(planLocation(location, "Param", param.Name), "param is also set by the matrix")
+				}
+			}
+		}
+
+		parent := matrixParams(g.values, g.source, suiteParams)
+		before := len(v.errors)
+		v.validateParams(location, xmlTest.Params, ParamSource{Kind: ParamFromTest}, parent, suiteName, xmlTest.Name, g.name)
+		found := v.errors[before:]
+		v.errors = v.errors[:before]
+		for _, e := range found {
+			if !reported[e] {
+				reported[e] = true
+				v.addError(e.Location, "%s, for test '%s'", e.Message, g.name)
+			}
+		}
+	}
+}
+
+// validateParams checks the params of an element and returns them resolved
+// and merged with parent, like AddTestPlan()
+func (v *planValidator) validateParams(location string, params []XMLParam, source ParamSource,
+	parent *Parameters, suiteName string, testNames ...string) *Parameters {
+	paramLocation := func(i int) string {
+		if params[i].Name == "" {
+			return fmt.Sprintf("%s/Param[%d]", location, i+1)
+		}
+		return planLocation(location, "Param", params[i].Name)
+	}
+	for i, param := range params {
+		if param.Name == "" {
+			v.addError(paramLocation(i), "param has no name")
+		}
+	}
+	resolved, errs := resolveParams(params, source, parent, v.overrides, suiteName, testNames...)
+	for _, e := range errs {
+		v.addError(paramLocation(e.index), "%s", e.err.Error())
+	}
+	return resolved
+}
+
+// ValidatePlanFile reads the test plan fileName, like RunFromPlan(), and
+// returns the parse error or the PlanErrors of ValidateTestPlan()
+func (tm *TestManager) ValidatePlanFile(fileName string, registry TestRegister) error {
+	var testPlan XMLTestPlan
+	if err := tm.ParseTestPlan(fileName, &testPlan); err != nil {
+		return err
+	}
+	if err := tm.ValidateTestPlan(&testPlan, registry); err != nil {
+		if planErrors, ok := err.(PlanErrors); ok {
+			planErrors.setFile(fileName)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2013 The goQA Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.package goQA
+
+package goQA
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// hookInits counts Init() calls of initHook
+var hookInits int
+
+// initHook counts its Init() calls in hookInits
+type initHook struct {
+	orderHook
+}
+
+func (h *initHook) Init(parent Manager, params Parameters) {
+	hookInits++
+}
+
+// validateRegister has countingTest as class "counting" and initHook as hook class "init"
+func validateRegister() *DefaultRegister {
+	return &DefaultRegister{
+		Registry: map[string]reflect.Type{"counting": reflect.TypeOf(countingTest{})},
+		Hooks:    map[string]reflect.Type{"init": reflect.TypeOf(initHook{})},
+	}
+}
+
+func parseXMLPlan(t *testing.T, content string) *XMLTestPlan {
+	var plan XMLTestPlan
+	if err := xml.Unmarshal([]byte(content), &plan); err != nil {
+		t.Fatal(err)
+	}
+	return &plan
+}
+
+func TestValidateTestPlan(t *testing.T) {
+	plan := parseXMLPlan(t, `
+<TestManager name="Manager">
+  <Param name="count" type="int">ten</Param>
+  <Hook class="Missing"/>
+  <TestSuite name="suite1" class="DefaultSuite">
+    <TestCase name="test1" class="counting" dependsOn="test2, missing">
+      <Param name="val1" type="bogus">1</Param>
+      <Param name="val2" type="string">${undefined}</Param>
+    </TestCase>
+    <TestCase name="test2" class="nothing"/>
+    <TestCase name="test2" class="counting"/>
+  </TestSuite>
+  <TestSuite name="suite1" class="NoSuchSuite"/>
+</TestManager>`)
+	err := newTestManager().ValidateTestPlan(plan, validateRegister())
+	planErrors, ok := err.(PlanErrors)
+	if !ok {
+		t.Fatalf("want PlanErrors, got %v", err)
+	}
+	locations := map[string]string{}
+	for _, e := range planErrors {
+		locations[e.Location] += e.Message + "\n"
+	}
+	test1 := "/TestManager/TestSuite[@name='suite1']/TestCase[@name='test1']"
+	for location, message := range map[string]string{
+		"/TestManager/Param[@name='count']":                                 "ten",
+		"/TestManager/Hook[1]":                                              "unknown hook class 'Missing'",
+		test1 + "/Param[@name='val1']":                                      "bogus",
+		test1 + "/Param[@name='val2']":                                      "undefined",
+		test1 + "/@dependsOn":                                               "depends on unknown test 'missing'",
+		"/TestManager/TestSuite[@name='suite1']/TestCase[@name='test2']":    "unknown test class 'nothing'",
+		"/TestManager/TestSuite[@name='suite1']/TestCase[@name='test2'][2]": "duplicate test name 'test2'",
+		"/TestManager/TestSuite[@name='suite1'][2]":                         "duplicate suite name 'suite1'",
+	} {
+		if !strings.Contains(locations[location], message) {
+			t.Errorf("no error %q at %s in:\n%s", message, location, planErrors)
+		}
+	}
+	if !strings.Contains(locations["/TestManager/TestSuite[@name='suite1'][2]"], "suite has no test cases") ||
+		!strings.Contains(locations["/TestManager/TestSuite[@name='suite1'][2]"], "unknown suite class 'NoSuchSuite'") {
+		t.Errorf("errors of the second suite:\n%s", locations["/TestManager/TestSuite[@name='suite1'][2]"])
+	}
+
+	valid := parseXMLPlan(t, `
+<TestManager name="Manager">
+  <TestSuite name="suite1" class="DefaultSuite">
+    <Param name="count" type="int">10</Param>
+    <TestCase name="test1" class="counting" dependsOn="test2"/>
+    <TestCase name="test2" class="counting"/>
+  </TestSuite>
+</TestManager>`)
+	if err := newTestManager().ValidateTestPlan(valid, validateRegister()); err != nil {
+		t.Errorf("valid plan has errors: %s", err)
+	}
+}
+
+func TestValidatePlanFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "plan.xml")
+	content := `<TestManager name="Manager"><TestSuite name="suite1"><TestCase name="test1" class="nothing"/></TestSuite></TestManager>`
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := newTestManager().ValidatePlanFile(file, validateRegister())
+	want := file + ":/TestManager/TestSuite[@name='suite1']/TestCase[@name='test1']: unknown test class 'nothing'"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+
+	tm := newTestManager()
+	if err := tm.RunFromPlan(file, validateRegister()); err == nil || len(tm.suites) != 0 {
+		t.Errorf("invalid plan was added")
+	}
+}
+
+func TestValidateCreatesNothing(t *testing.T) {
+	plan := parseXMLPlan(t, `
+<TestManager name="Manager">
+  <Hook class="init"/>
+  <TestSuite name="suite1">
+    <TestCase name="test1" class="counting"/>
+  </TestSuite>
+</TestManager>`)
+	hookInits = 0
+	tm := newTestManager()
+	if err := tm.ValidateTestPlan(plan, validateRegister()); err != nil {
+		t.Fatalf("valid plan has errors: %s", err)
+	}
+	if hookInits != 0 || len(tm.hooks) != 0 || len(tm.suites) != 0 {
+		t.Errorf("validation created %d hook(s) and %d suite(s)", hookInits, len(tm.suites))
+	}
+	if err := tm.AddTestPlan(plan, validateRegister()); err != nil {
+		t.Fatal(err)
+	}
+	if hookInits != 1 {
+		t.Errorf("want 1 hook Init() from AddTestPlan(), got %d", hookInits)
+	}
+}